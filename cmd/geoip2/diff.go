@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+func runDiff(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: geoip2 diff [flags] OLD.mmdb NEW.mmdb")
+		flags.PrintDefaults()
+	}
+
+	var countries, asns, fields listFlag
+	flags.Var(&countries, "country", "only report networks in these `countries` (comma-separated ISO codes)")
+	flags.Var(&asns, "asn", "only report networks in these `ASNs` (comma-separated)")
+	flags.Var(&fields, "fields", "compare only these `fields`: country, city, asn, anonymizer")
+	summaryOnly := flags.Bool("summary", false, "only print the summary")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	options := geoip2.DiffOptions{Countries: countries}
+	var err error
+	if options.ASNs, err = asns.uints(); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		field, ok := map[string]geoip2.DiffField{
+			"country":    geoip2.DiffCountry,
+			"city":       geoip2.DiffCity,
+			"asn":        geoip2.DiffASN,
+			"anonymizer": geoip2.DiffAnonymizer,
+		}[strings.ToLower(f)]
+		if !ok {
+			return fmt.Errorf("unknown field %q", f)
+		}
+		options.Fields |= field
+	}
+
+	oldDB, err := geoip2.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer oldDB.Close()
+
+	newDB, err := geoip2.Open(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer newDB.Close()
+
+	report, err := geoip2.Diff(oldDB, newDB, options)
+	if err != nil {
+		return err
+	}
+
+	if !*summaryOnly {
+		for _, entry := range report.Entries {
+			if _, err := fmt.Fprintln(stdout, formatDiffEntry(entry)); err != nil {
+				return err
+			}
+		}
+	}
+
+	s := report.Summary
+	_, err = fmt.Fprintf(
+		stdout,
+		"added: %d, removed: %d, changed: %d (country: %d, city: %d, asn: %d, anonymizer: %d)\n",
+		s.Added, s.Removed, s.Changed,
+		s.CountryChanged, s.CityChanged, s.ASNChanged, s.AnonymizerChanged,
+	)
+	return err
+}
+
+func formatDiffEntry(entry geoip2.DiffEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-8s %s", entry.Kind, entry.Network)
+
+	switch entry.Kind {
+	case geoip2.NetworkAdded:
+		b.WriteString(" " + formatDiffRecord(entry.New))
+	case geoip2.NetworkRemoved:
+		b.WriteString(" " + formatDiffRecord(entry.Old))
+	case geoip2.NetworkChanged:
+		if entry.Changed&geoip2.DiffCountry != 0 {
+			fmt.Fprintf(&b, " country=%s->%s", entry.Old.CountryIsoCode, entry.New.CountryIsoCode)
+		}
+		if entry.Changed&geoip2.DiffCity != 0 {
+			fmt.Fprintf(&b, " city=%q->%q", entry.Old.CityName, entry.New.CityName)
+		}
+		if entry.Changed&geoip2.DiffASN != 0 {
+			fmt.Fprintf(
+				&b,
+				" asn=%d->%d",
+				entry.Old.AutonomousSystemNumber,
+				entry.New.AutonomousSystemNumber,
+			)
+		}
+		if entry.Changed&geoip2.DiffAnonymizer != 0 {
+			fmt.Fprintf(
+				&b,
+				" anonymizer=%s->%s",
+				anonymizerFlags(entry.Old),
+				anonymizerFlags(entry.New),
+			)
+		}
+	}
+	return b.String()
+}
+
+func formatDiffRecord(r geoip2.DiffRecord) string {
+	var parts []string
+	if r.CountryIsoCode != "" {
+		parts = append(parts, "country="+r.CountryIsoCode)
+	}
+	if r.CityName != "" {
+		parts = append(parts, fmt.Sprintf("city=%q", r.CityName))
+	}
+	if r.AutonomousSystemNumber != 0 {
+		parts = append(parts, fmt.Sprintf("asn=%d", r.AutonomousSystemNumber))
+	}
+	if flags := anonymizerFlags(r); flags != "-" {
+		parts = append(parts, "anonymizer="+flags)
+	}
+	return strings.Join(parts, " ")
+}
+
+func anonymizerFlags(r geoip2.DiffRecord) string {
+	var flags []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"anonymous", r.IsAnonymous},
+		{"anonymous_proxy", r.IsAnonymousProxy},
+		{"anonymous_vpn", r.IsAnonymousVPN},
+		{"hosting_provider", r.IsHostingProvider},
+		{"public_proxy", r.IsPublicProxy},
+		{"residential_proxy", r.IsResidentialProxy},
+		{"tor_exit_node", r.IsTorExitNode},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, ",")
+}
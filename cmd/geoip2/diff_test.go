@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDataDir = "../../test-data/test-data/"
+
+func TestDiffCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run(
+		[]string{
+			"diff",
+			"-country", "GB",
+			testDataDir + "GeoIP2-Country-Test.mmdb",
+			testDataDir + "GeoIP2-City-Test.mmdb",
+		},
+		&stdout,
+		&stderr,
+	)
+	assert.Equal(t, 0, code, stderr.String())
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Greater(t, len(lines), 1)
+	assert.Contains(t, stdout.String(), `city=""->"London"`)
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "added: "), lines[len(lines)-1])
+}
+
+func TestDiffCommandSummary(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run(
+		[]string{
+			"diff",
+			"-summary",
+			testDataDir + "GeoIP2-City-Test.mmdb",
+			testDataDir + "GeoIP2-City-Test.mmdb",
+		},
+		&stdout,
+		&stderr,
+	)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(
+		t,
+		"added: 0, removed: 0, changed: 0 (country: 0, city: 0, asn: 0, anonymizer: 0)\n",
+		stdout.String(),
+	)
+}
+
+func TestDiffCommandErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"diff"},
+		{"diff", "-fields", "bogus", "a.mmdb", "b.mmdb"},
+		{"diff", "does-not-exist.mmdb", "does-not-exist.mmdb"},
+		{"bogus"},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert.NotEqual(t, 0, run(args, &stdout, &stderr), args)
+		assert.NotEmpty(t, stderr.String(), args)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+func runExport(args []string, _, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: geoip2 export [flags] DATABASE.mmdb")
+		flags.PrintDefaults()
+	}
+
+	locales := listFlag{}
+	flags.Var(&locales, "locales", "write Locations files for these `locales` (comma-separated, default en)")
+	dir := flags.String("dir", ".", "write the CSV files to `directory`")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	db, err := geoip2.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return geoip2.ExportCSVFiles(db, *dir, locales...)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	code := run(
+		[]string{
+			"export",
+			"-dir", dir,
+			"-locales", "en,ru",
+			testDataDir + "GeoIP2-City-Test.mmdb",
+		},
+		&stdout,
+		&stderr,
+	)
+	assert.Equal(t, 0, code, stderr.String())
+
+	for _, name := range []string{
+		"GeoIP2-City-Blocks-IPv4.csv",
+		"GeoIP2-City-Blocks-IPv6.csv",
+		"GeoIP2-City-Locations-en.csv",
+		"GeoIP2-City-Locations-ru.csv",
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,100 @@
+// Command geoip2 provides tools for working with GeoIP2 and GeoLite2
+// databases.
+//
+// Usage:
+//
+//	geoip2 diff [flags] OLD.mmdb NEW.mmdb
+//	geoip2 export [flags] DATABASE.mmdb
+//
+// The diff command reports the networks whose country, city, ASN or
+// anonymizer flags differ between two releases of a database.
+//
+// The export command writes a City, Country, Enterprise or ASN database as
+// CSV files in the layout of MaxMind's CSV databases.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type command struct {
+	run     func(args []string, stdout, stderr io.Writer) error
+	name    string
+	summary string
+}
+
+var commands = []command{
+	{name: "diff", summary: "report changes between two databases", run: runDiff},
+	{name: "export", summary: "export a database to CSV files", run: runExport},
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], stdout, stderr)
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "geoip2 %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "geoip2: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: geoip2 <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// listFlag is a flag.Value holding a comma-separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func (l listFlag) uints() ([]uint, error) {
+	out := make([]uint, 0, len(l))
+	for _, v := range l {
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(v), "AS"), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", v)
+		}
+		out = append(out, uint(n))
+	}
+	return out, nil
+}
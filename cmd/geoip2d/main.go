@@ -0,0 +1,173 @@
+// Command geoip2d serves lookups in GeoIP2 and GeoLite2 databases over
+// HTTP as JSON.
+//
+// Usage:
+//
+//	geoip2d [flags] DATABASE.mmdb...
+//
+// The endpoints are:
+//
+//	GET  /{method}/{ip}  look up ip
+//	POST /{method}       look up a JSON array of IP addresses
+//	GET  /health         report whether databases are loaded
+//	GET  /metadata       return the metadata of the loaded databases
+//	GET  /metrics        return lookup metrics in the Prometheus text format
+//
+// where method is one of anonymous-ip, asn, city, connection-type,
+// country, domain, enterprise or isp. Each lookup uses the first database,
+// in the order given on the command line, that supports the method. The
+// records are encoded with the keys used in the databases, e.g., iso_code,
+// and addresses that are not in the database are answered with 404 Not
+// Found.
+//
+// With -account, the server also emulates the GeoIP2 Country, City and
+// Insights web services at /geoip/v2.1/{country,city,insights}/{ip}, so that
+// clients of the web services can be pointed at it. Requests must use basic
+// authentication with one of the given account IDs and license keys.
+// Responses use the JSON schema and error codes of the web services, e.g.,
+// IP_ADDRESS_RESERVED for private addresses and IP_ADDRESS_NOT_FOUND for
+// addresses that are not in the database. Insights is backed by an
+// Enterprise database and includes the flags of an Anonymous IP database if
+// one is loaded.
+//
+// The databases are reopened when the process receives SIGHUP and, with
+// -watch, when the modification time of any of their files changes. If a
+// database cannot be opened, the previously loaded ones are kept. As the
+// files are memory-mapped, they must be replaced by renaming a new file over
+// them rather than rewritten in place.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("geoip2d", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: geoip2d [flags] DATABASE.mmdb...")
+		flags.PrintDefaults()
+	}
+
+	addr := flags.String("listen", "localhost:8080", "listen on `address`")
+	accounts := accountsFlag{}
+	flags.Var(accounts, "account",
+		"emulate the GeoIP2 web services for `ACCOUNT_ID:LICENSE_KEY` (may be repeated)")
+	watch := flags.Duration("watch", 0,
+		"check the database files for changes every `interval` and reload them (0 disables)")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	logger := log.New(stderr, "geoip2d: ", log.LstdFlags)
+	if err := serve(ctx, *addr, *watch, flags.Args(), accounts, logger); err != nil {
+		logger.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func serve(
+	ctx context.Context,
+	addr string,
+	watch time.Duration,
+	paths []string,
+	accounts map[string]string,
+	logger *log.Logger,
+) error {
+	s, err := newServer(paths)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+	s.accounts = accounts
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go reloadLoop(ctx, s, watch, logger)
+
+	errc := make(chan error, 1)
+	go func() {
+		logger.Printf("listening on %s", addr)
+		errc <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// reloadLoop reloads the databases of s on SIGHUP and, if watch is not
+// zero, when their files change, until ctx is done.
+func reloadLoop(ctx context.Context, s *server, watch time.Duration, logger *log.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if watch > 0 {
+		ticker := time.NewTicker(watch)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := s.reload(); err != nil {
+				logger.Printf("reload failed: %v", err)
+				continue
+			}
+			logger.Print("reloaded databases")
+		case <-tick:
+			reloaded, err := s.reloadIfChanged()
+			if err != nil {
+				logger.Printf("reload failed: %v", err)
+				continue
+			}
+			if reloaded {
+				logger.Print("reloaded changed databases")
+			}
+		}
+	}
+}
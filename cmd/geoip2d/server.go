@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/oschwald/geoip2-golang/geoip2metrics"
+)
+
+// maxBatchSize is the maximum number of IP addresses in a batch request.
+const maxBatchSize = 1000
+
+// maxBatchBytes limits the size of the body of a batch request.
+const maxBatchBytes = 1 << 20
+
+// lookups maps the path segments of the endpoints to the lookup methods.
+var lookups = map[string]string{
+	"anonymous-ip":    "AnonymousIP",
+	"asn":             "ASN",
+	"city":            "City",
+	"connection-type": "ConnectionType",
+	"country":         "Country",
+	"domain":          "Domain",
+	"enterprise":      "Enterprise",
+	"isp":             "ISP",
+}
+
+// countryKeys are the top-level keys of Country records. Country lookups in
+// City and Enterprise databases are restricted to them.
+var countryKeys = []string{"continent", "country", "registered_country", "represented_country", "traits"}
+
+type database struct {
+	reader  *geoip2.Reader
+	loaded  time.Time
+	modTime time.Time
+	path    string
+}
+
+// server serves lookups in a set of databases that can be reloaded while
+// it is running.
+type server struct {
+	// accounts maps the account IDs accepted by the emulated web services
+	// to their license keys. The web services are disabled if it is empty.
+	accounts map[string]string
+	metrics  *geoip2metrics.Metrics
+	dbs      []*database
+	paths    []string
+	// mu guards dbs. Lookups hold it for reading so that a reload does not
+	// close a reader while it is in use.
+	mu sync.RWMutex
+}
+
+func newServer(paths []string) (*server, error) {
+	s := &server{metrics: geoip2metrics.NewMetrics(geoip2metrics.Config{}), paths: paths}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload opens all databases again and replaces the current ones. If any
+// database fails to open, the current ones are kept.
+func (s *server) reload() error {
+	dbs := make([]*database, 0, len(s.paths))
+	for _, path := range s.paths {
+		db, err := openDatabase(path)
+		if err != nil {
+			closeDatabases(dbs)
+			return err
+		}
+		s.metrics.Instrument(db.reader)
+		dbs = append(dbs, db)
+	}
+
+	s.mu.Lock()
+	old := s.dbs
+	s.dbs = dbs
+	s.mu.Unlock()
+
+	closeDatabases(old)
+	return nil
+}
+
+// reloadIfChanged reloads the databases if the modification time of any of
+// their files changed. It reports whether they were reloaded.
+func (s *server) reloadIfChanged() (bool, error) {
+	s.mu.RLock()
+	changed := false
+	for _, db := range s.dbs {
+		info, err := os.Stat(db.path)
+		if err != nil || !info.ModTime().Equal(db.modTime) {
+			changed = true
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+	return true, s.reload()
+}
+
+func (s *server) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	closeDatabases(s.dbs)
+	s.dbs = nil
+}
+
+func openDatabase(path string) (*database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &database{
+		reader:  reader,
+		loaded:  time.Now(),
+		modTime: info.ModTime(),
+		path:    path,
+	}, nil
+}
+
+func closeDatabases(dbs []*database) {
+	for _, db := range dbs {
+		db.reader.Close()
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /metadata", s.handleMetadata)
+	mux.Handle("GET /metrics", s.metrics)
+	mux.HandleFunc("GET /{method}/{ip}", s.handleLookup)
+	mux.HandleFunc("POST /{method}", s.handleBatch)
+	if len(s.accounts) > 0 {
+		mux.HandleFunc("GET "+webServicePrefix+"{service}/{ip}", s.handleWebService)
+		mux.HandleFunc("GET "+webServicePrefix+"{service}/{$}", s.handleWebServiceNoIP)
+	}
+	return mux
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type healthResponse struct {
+	Status    string `json:"status"`
+	Databases int    `json:"databases"`
+}
+
+type metadataResponse struct {
+	Loaded   time.Time          `json:"loaded"`
+	Path     string             `json:"path"`
+	Metadata maxminddb.Metadata `json:"metadata"`
+}
+
+type batchResult struct {
+	Record any    `json:"record,omitempty"`
+	IP     string `json:"ip"`
+	Error  string `json:"error,omitempty"`
+}
+
+// errUnsupportedMethod is returned when none of the databases supports a
+// lookup method.
+var errUnsupportedMethod = errors.New("no database supports this lookup")
+
+// errNotFound is returned when a database has no record for an IP address.
+var errNotFound = errors.New("address not found")
+
+func (s *server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	n := len(s.dbs)
+	s.mu.RUnlock()
+
+	if n == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: "unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok", Databases: n})
+}
+
+func (s *server) handleMetadata(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	response := make([]metadataResponse, 0, len(s.dbs))
+	for _, db := range s.dbs {
+		response = append(response, metadataResponse{
+			Loaded:   db.loaded,
+			Path:     db.path,
+			Metadata: db.reader.Metadata(),
+		})
+	}
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *server) handleLookup(w http.ResponseWriter, r *http.Request) {
+	method, ok := lookups[r.PathValue("method")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown lookup method")
+		return
+	}
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid IP address %q", r.PathValue("ip")))
+		return
+	}
+
+	record, err := s.lookup(method, ip)
+	switch {
+	case errors.Is(err, errUnsupportedMethod), errors.Is(err, errNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, record)
+	}
+}
+
+// handleBatch looks up the IP addresses in a JSON array of strings. Errors
+// for individual addresses are reported in their results.
+func (s *server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	method, ok := lookups[r.PathValue("method")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown lookup method")
+		return
+	}
+	var ips []string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&ips); err != nil {
+		writeError(w, http.StatusBadRequest, "the body must be a JSON array of IP addresses")
+		return
+	}
+	if len(ips) > maxBatchSize {
+		writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("at most %d IP addresses may be looked up at once", maxBatchSize))
+		return
+	}
+
+	results := make([]batchResult, len(ips))
+	for i, value := range ips {
+		results[i].IP = value
+		ip := net.ParseIP(value)
+		if ip == nil {
+			results[i].Error = "invalid IP address"
+			continue
+		}
+		record, err := s.lookup(method, ip)
+		if errors.Is(err, errUnsupportedMethod) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Record = record
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// lookup returns the record for ip, with the keys used in the database, from
+// the first database that supports the lookup method.
+func (s *server) lookup(method string, ip net.IP) (map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.supporting(method)
+	if db == nil {
+		return nil, errUnsupportedMethod
+	}
+	var record map[string]any
+	_, found, err := db.reader.LookupNetwork(ip, &record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNotFound
+	}
+	if method == "Country" {
+		for key := range record {
+			if !slices.Contains(countryKeys, key) {
+				delete(record, key)
+			}
+		}
+	}
+	return record, nil
+}
+
+// supporting returns the first database that supports the lookup method
+// or nil if there is none. s.mu must be held.
+func (s *server) supporting(method string) *database {
+	for _, db := range s.dbs {
+		if db.reader.Supports(method) {
+			return db
+		}
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
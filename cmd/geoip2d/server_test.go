@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDataDir = "../../test-data/test-data/"
+
+func newTestServer(t *testing.T, paths ...string) (*server, *httptest.Server) {
+	t.Helper()
+
+	s, err := newServer(paths)
+	require.NoError(t, err)
+	t.Cleanup(s.close)
+
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func getJSON(t *testing.T, url string, v any) int {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
+
+func TestLookup(t *testing.T) {
+	_, ts := newTestServer(t,
+		testDataDir+"GeoIP2-City-Test.mmdb",
+		testDataDir+"GeoLite2-ASN-Test.mmdb",
+	)
+
+	var city struct {
+		City struct {
+			Names map[string]string `json:"names"`
+		} `json:"city"`
+		Country struct {
+			IsoCode string `json:"iso_code"`
+		} `json:"country"`
+	}
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/city/81.2.69.160", &city))
+	assert.Equal(t, "London", city.City.Names["en"])
+	assert.Equal(t, "GB", city.Country.IsoCode)
+
+	// The City database supports Country lookups too.
+	var country map[string]any
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/country/2001:218::", &country))
+	assert.Equal(t, "JP", country["country"].(map[string]any)["iso_code"])
+	assert.NotContains(t, country, "city")
+	assert.NotContains(t, country, "location")
+
+	var asn struct {
+		AutonomousSystemOrganization string `json:"autonomous_system_organization"`
+		AutonomousSystemNumber       uint   `json:"autonomous_system_number"`
+	}
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/asn/1.128.0.0", &asn))
+	assert.Equal(t, uint(1221), asn.AutonomousSystemNumber)
+	assert.Equal(t, "Telstra Pty Ltd", asn.AutonomousSystemOrganization)
+}
+
+func TestLookupErrors(t *testing.T) {
+	_, ts := newTestServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/city/not-an-ip", http.StatusBadRequest},
+		{"/city/10.0.0.1", http.StatusNotFound},
+		{"/isp/81.2.69.160", http.StatusNotFound},
+		{"/nope/81.2.69.160", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			var response errorResponse
+			assert.Equal(t, test.status, getJSON(t, ts.URL+test.path, &response))
+			assert.NotEmpty(t, response.Error)
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	_, ts := newTestServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	resp, err := http.Post(ts.URL+"/country", "application/json",
+		strings.NewReader(`["81.2.69.160", "bogus", "89.160.20.112", "10.0.0.1"]`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var results []struct {
+		Record *struct {
+			Country struct {
+				IsoCode string `json:"iso_code"`
+			} `json:"country"`
+		} `json:"record"`
+		IP    string `json:"ip"`
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "81.2.69.160", results[0].IP)
+	assert.Equal(t, "GB", results[0].Record.Country.IsoCode)
+	assert.Equal(t, "bogus", results[1].IP)
+	assert.Nil(t, results[1].Record)
+	assert.NotEmpty(t, results[1].Error)
+	assert.Equal(t, "SE", results[2].Record.Country.IsoCode)
+	assert.Nil(t, results[3].Record)
+	assert.Equal(t, errNotFound.Error(), results[3].Error)
+}
+
+func TestBatchErrors(t *testing.T) {
+	_, ts := newTestServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	tooMany, err := json.Marshal(make([]string, maxBatchSize+1))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid body", "/city", `{"ip": "81.2.69.160"}`, http.StatusBadRequest},
+		{"too many", "/city", string(tooMany), http.StatusRequestEntityTooLarge},
+		{"unsupported", "/asn", `["81.2.69.160"]`, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+test.path, "application/json", strings.NewReader(test.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestHealthAndMetadata(t *testing.T) {
+	_, ts := newTestServer(t,
+		testDataDir+"GeoIP2-City-Test.mmdb",
+		testDataDir+"GeoLite2-ASN-Test.mmdb",
+	)
+
+	var health healthResponse
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/health", &health))
+	assert.Equal(t, healthResponse{Status: "ok", Databases: 2}, health)
+
+	var metadata []struct {
+		Path     string `json:"path"`
+		Metadata struct {
+			DatabaseType string
+		} `json:"metadata"`
+	}
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/metadata", &metadata))
+	require.Len(t, metadata, 2)
+	assert.Equal(t, "GeoIP2-City", metadata[0].Metadata.DatabaseType)
+	assert.Equal(t, "GeoLite2-ASN", metadata[1].Metadata.DatabaseType)
+}
+
+// replaceFile replaces dst with data by renaming a temporary file over it,
+// as database updaters do, since the current file is memory-mapped.
+func replaceFile(t *testing.T, dst string, data []byte) {
+	t.Helper()
+
+	tmp := dst + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0o600))
+	require.NoError(t, os.Rename(tmp, dst))
+}
+
+func TestMetrics(t *testing.T) {
+	_, ts := newTestServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	var city map[string]any
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/city/81.2.69.160", &city))
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `geoip2_lookups_total{method="LookupNetwork",database_type="GeoIP2-City"} 1`)
+	assert.Contains(t, string(body), `geoip2_database_build_age_seconds{database_type="GeoIP2-City"}`)
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	replaceFile(t, dst, data)
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.mmdb")
+	copyFile(t, testDataDir+"GeoLite2-ASN-Test.mmdb", path)
+
+	s, ts := newTestServer(t, path)
+
+	var response errorResponse
+	require.Equal(t, http.StatusNotFound, getJSON(t, ts.URL+"/city/81.2.69.160", &response))
+
+	reloaded, err := s.reloadIfChanged()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	copyFile(t, testDataDir+"GeoIP2-City-Test.mmdb", path)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	reloaded, err = s.reloadIfChanged()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	var city struct {
+		City struct {
+			Names map[string]string
+		}
+	}
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/city/81.2.69.160", &city))
+	assert.Equal(t, "London", city.City.Names["en"])
+
+	// A database that fails to open keeps the current ones loaded.
+	replaceFile(t, path, []byte("not a database"))
+	require.Error(t, s.reload())
+	require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/city/81.2.69.160", &city))
+}
+
+func TestRunUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	assert.Equal(t, 2, run(context.Background(), nil, &stderr))
+	assert.Contains(t, stderr.String(), "Usage: geoip2d")
+}
+
+func TestRunOpenError(t *testing.T) {
+	var stderr bytes.Buffer
+	code := run(context.Background(), []string{"-listen", "127.0.0.1:0", "missing.mmdb"}, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "missing.mmdb")
+}
+
+func TestRunShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"-listen", "127.0.0.1:0", testDataDir + "GeoIP2-City-Test.mmdb"}, io.Discard)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case code := <-done:
+		assert.Equal(t, 0, code)
+	case <-time.After(15 * time.Second):
+		t.Fatal("run did not return after the context was canceled")
+	}
+}
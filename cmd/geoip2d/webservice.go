@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang/geoip2http"
+)
+
+// webServicePrefix is the path prefix of the GeoIP2 web services.
+const webServicePrefix = "/geoip/v2.1/"
+
+// queriesRemaining is reported in the maxmind object of responses. Local
+// lookups are not metered.
+const queriesRemaining = math.MaxInt32
+
+// webService describes one of the emulated GeoIP2 web services.
+type webService struct {
+	// method is the lookup method that databases must support to back the
+	// service.
+	method string
+	// keys are the top-level keys of the record included in responses. If
+	// nil, the complete record is included.
+	keys []string
+	// anonymousIP adds the flags of an Anonymous IP database, if one is
+	// loaded, to the traits of responses.
+	anonymousIP bool
+}
+
+var webServices = map[string]webService{
+	"country": {
+		method: "Country",
+		keys:   countryKeys,
+	},
+	"city": {
+		method: "City",
+		keys: []string{
+			"city", "continent", "country", "location", "postal",
+			"registered_country", "represented_country", "subdivisions", "traits",
+		},
+	},
+	"insights": {
+		method:      "Enterprise",
+		anonymousIP: true,
+	},
+}
+
+// The codes of the errors returned by the GeoIP2 web services.
+const (
+	codeAccountIDRequired    = "ACCOUNT_ID_REQUIRED"
+	codeAccountIDUnknown     = "ACCOUNT_ID_UNKNOWN"
+	codeAuthorizationInvalid = "AUTHORIZATION_INVALID"
+	codeIPAddressInvalid     = "IP_ADDRESS_INVALID"
+	codeIPAddressNotFound    = "IP_ADDRESS_NOT_FOUND"
+	codeIPAddressRequired    = "IP_ADDRESS_REQUIRED"
+	codeIPAddressReserved    = "IP_ADDRESS_RESERVED"
+	codeLicenseKeyRequired   = "LICENSE_KEY_REQUIRED"
+	codePermissionRequired   = "PERMISSION_REQUIRED"
+)
+
+// reservedNetworks are the networks for which the web services return
+// IP_ADDRESS_RESERVED.
+var reservedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// accountsFlag is a flag.Value holding ACCOUNT_ID:LICENSE_KEY pairs.
+type accountsFlag map[string]string
+
+func (a accountsFlag) String() string {
+	ids := make([]string, 0, len(a))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return strings.Join(ids, ",")
+}
+
+func (a accountsFlag) Set(s string) error {
+	id, key, ok := strings.Cut(s, ":")
+	if !ok || id == "" || key == "" {
+		return errors.New("must be ACCOUNT_ID:LICENSE_KEY")
+	}
+	a[id] = key
+	return nil
+}
+
+type webServiceError struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// handleWebService emulates the GeoIP2 Country, City and Insights web
+// services, including their authentication, response schema and errors.
+func (s *server) handleWebService(w http.ResponseWriter, r *http.Request) {
+	service, ok := webServices[r.PathValue("service")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+
+	ip, ok := webServiceIP(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := s.webServiceRecord(service, ip)
+	switch {
+	case errors.Is(err, errUnsupportedMethod):
+		writeWebServiceError(w, http.StatusForbidden, codePermissionRequired,
+			"You do not have permission to use the service.")
+	case errors.Is(err, errNotFound):
+		writeWebServiceError(w, http.StatusNotFound, codeIPAddressNotFound,
+			fmt.Sprintf("The address %s is not in the database.", ip))
+	case err != nil:
+		writeWebServiceError(w, http.StatusInternalServerError, "", err.Error())
+	default:
+		w.Header().Set("Content-Type",
+			fmt.Sprintf("application/vnd.maxmind.com-%s+json; charset=UTF-8; version=2.1", r.PathValue("service")))
+		_ = json.NewEncoder(w).Encode(record)
+	}
+}
+
+// handleWebServiceNoIP answers requests without an IP address, which the web
+// services reject once the credentials have been checked.
+func (s *server) handleWebServiceNoIP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := webServices[r.PathValue("service")]; !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+	writeWebServiceError(w, http.StatusBadRequest, codeIPAddressRequired,
+		"You have not supplied an IP address, which is a required field.")
+}
+
+// authorize checks the basic authentication credentials of r against the
+// configured accounts and writes an error response if they are invalid.
+func (s *server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	id, key, _ := r.BasicAuth()
+	switch want, known := s.accounts[id]; {
+	case id == "":
+		writeWebServiceError(w, http.StatusUnauthorized, codeAccountIDRequired,
+			"You have not supplied a MaxMind account ID in the Authorization header.")
+	case key == "":
+		writeWebServiceError(w, http.StatusUnauthorized, codeLicenseKeyRequired,
+			"You have not supplied a MaxMind license key in the Authorization header.")
+	case !known:
+		writeWebServiceError(w, http.StatusUnauthorized, codeAccountIDUnknown,
+			"We could not find your account ID.")
+	case subtle.ConstantTimeCompare([]byte(key), []byte(want)) != 1:
+		writeWebServiceError(w, http.StatusUnauthorized, codeAuthorizationInvalid,
+			"You have supplied an invalid MaxMind account ID and/or license key in the Authorization header.")
+	default:
+		return true
+	}
+	return false
+}
+
+// webServiceIP returns the IP address requested by r, where "me" is the
+// address of the client, and writes an error response if it is invalid or
+// reserved.
+func webServiceIP(w http.ResponseWriter, r *http.Request) (netip.Addr, bool) {
+	value := r.PathValue("ip")
+	var ip netip.Addr
+	if value == "me" {
+		ip, _ = geoip2http.ClientAddr(r, nil)
+	} else if parsed, err := netip.ParseAddr(value); err == nil && parsed.Zone() == "" {
+		ip = parsed.Unmap()
+	}
+	if !ip.IsValid() {
+		writeWebServiceError(w, http.StatusBadRequest, codeIPAddressInvalid,
+			fmt.Sprintf("The value %q is not a valid IP address.", value))
+		return ip, false
+	}
+	for _, prefix := range reservedNetworks {
+		if prefix.Contains(ip) {
+			writeWebServiceError(w, http.StatusBadRequest, codeIPAddressReserved,
+				fmt.Sprintf("The IP address supplied (%s) is in a reserved network.", ip))
+			return ip, false
+		}
+	}
+	return ip, true
+}
+
+// webServiceRecord returns the response to a request for ip from service:
+// the record of the first database that supports the service, restricted
+// to the keys of the service, with the traits the web services add.
+func (s *server) webServiceRecord(service webService, ip netip.Addr) (map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.supporting(service.method)
+	if db == nil {
+		return nil, errUnsupportedMethod
+	}
+	var record map[string]any
+	network, found, err := db.reader.LookupNetwork(ip.AsSlice(), &record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNotFound
+	}
+	if service.keys != nil {
+		for key := range record {
+			if !slices.Contains(service.keys, key) {
+				delete(record, key)
+			}
+		}
+	}
+
+	traits, _ := record["traits"].(map[string]any)
+	if traits == nil {
+		traits = map[string]any{}
+		record["traits"] = traits
+	}
+	traits["ip_address"] = ip.String()
+	traits["network"] = network.String()
+
+	if anonymous := s.supporting("AnonymousIP"); service.anonymousIP && anonymous != nil {
+		var flags map[string]any
+		if _, _, err := anonymous.reader.LookupNetwork(ip.AsSlice(), &flags); err != nil {
+			return nil, err
+		}
+		for flag, value := range flags {
+			traits[flag] = value
+		}
+	}
+
+	record["maxmind"] = map[string]any{"queries_remaining": queriesRemaining}
+	return record, nil
+}
+
+func writeWebServiceError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/vnd.maxmind.com-error+json; charset=UTF-8; version=2.0")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(webServiceError{Code: code, Error: message})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebServiceServer(t *testing.T, paths ...string) *httptest.Server {
+	t.Helper()
+
+	s, err := newServer(paths)
+	require.NoError(t, err)
+	t.Cleanup(s.close)
+	s.accounts = map[string]string{"42": "secret"}
+
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func getWebService(t *testing.T, url, id, key string) (*http.Response, map[string]any) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if id != "" || key != "" {
+		req.SetBasicAuth(id, key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp, body
+}
+
+func TestWebServiceCity(t *testing.T) {
+	ts := newWebServiceServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	resp, body := getWebService(t, ts.URL+"/geoip/v2.1/city/81.2.69.160", "42", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t,
+		"application/vnd.maxmind.com-city+json; charset=UTF-8; version=2.1",
+		resp.Header.Get("Content-Type"),
+	)
+
+	assert.Equal(t, "London", body["city"].(map[string]any)["names"].(map[string]any)["en"])
+	assert.Equal(t, "GB", body["country"].(map[string]any)["iso_code"])
+	assert.Equal(t, map[string]any{
+		"ip_address": "81.2.69.160",
+		"network":    "81.2.69.128/26",
+	}, body["traits"])
+	assert.Equal(t, map[string]any{"queries_remaining": float64(queriesRemaining)}, body["maxmind"])
+}
+
+func TestWebServiceCountry(t *testing.T) {
+	ts := newWebServiceServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	resp, body := getWebService(t, ts.URL+"/geoip/v2.1/country/81.2.69.160", "42", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t,
+		"application/vnd.maxmind.com-country+json; charset=UTF-8; version=2.1",
+		resp.Header.Get("Content-Type"),
+	)
+
+	assert.Equal(t, "GB", body["country"].(map[string]any)["iso_code"])
+	assert.NotContains(t, body, "city")
+	assert.NotContains(t, body, "location")
+	assert.NotContains(t, body, "subdivisions")
+}
+
+func TestWebServiceInsights(t *testing.T) {
+	ts := newWebServiceServer(t,
+		testDataDir+"GeoIP2-Enterprise-Test.mmdb",
+		testDataDir+"GeoIP2-Anonymous-IP-Test.mmdb",
+	)
+
+	resp, body := getWebService(t, ts.URL+"/geoip/v2.1/insights/74.209.24.0", "42", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	traits := body["traits"].(map[string]any)
+	assert.Equal(t, "Cable/DSL", traits["connection_type"])
+	assert.Equal(t, "residential", traits["user_type"])
+	assert.Equal(t, "74.209.24.0/24", traits["network"])
+	assert.NotContains(t, traits, "is_anonymous")
+
+	resp, body = getWebService(t, ts.URL+"/geoip/v2.1/insights/81.2.69.160", "42", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	traits = body["traits"].(map[string]any)
+	assert.Equal(t, true, traits["is_anonymous"])
+	assert.Equal(t, true, traits["is_tor_exit_node"])
+
+	// Choosing the databases must not perform lookups of its own.
+	metrics, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer metrics.Body.Close()
+	text, err := io.ReadAll(metrics.Body)
+	require.NoError(t, err)
+	for _, databaseType := range []string{"GeoIP2-Enterprise", "GeoIP2-Anonymous-IP"} {
+		labels := `{method="LookupNetwork",database_type="` + databaseType + `"}`
+		assert.Contains(t, string(text), "geoip2_lookups_total"+labels+" 2")
+		assert.Contains(t, string(text), "geoip2_lookup_errors_total"+labels+" 0")
+	}
+	assert.NotContains(t, string(text), `method="Enterprise"`)
+	assert.NotContains(t, string(text), `method="AnonymousIP"`)
+}
+
+func TestWebServiceErrors(t *testing.T) {
+	ts := newWebServiceServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	tests := []struct {
+		name   string
+		path   string
+		id     string
+		key    string
+		code   string
+		status int
+	}{
+		{"no credentials", "city/81.2.69.160", "", "", codeAccountIDRequired, http.StatusUnauthorized},
+		{"no license key", "city/81.2.69.160", "42", "", codeLicenseKeyRequired, http.StatusUnauthorized},
+		{"unknown account", "city/81.2.69.160", "7", "secret", codeAccountIDUnknown, http.StatusUnauthorized},
+		{"wrong license key", "city/81.2.69.160", "42", "guess", codeAuthorizationInvalid, http.StatusUnauthorized},
+		{"invalid", "city/1.2.3", "42", "secret", codeIPAddressInvalid, http.StatusBadRequest},
+		{"required", "city/", "42", "secret", codeIPAddressRequired, http.StatusBadRequest},
+		{"reserved", "city/10.1.2.3", "42", "secret", codeIPAddressReserved, http.StatusBadRequest},
+		{"reserved IPv6", "country/fe80::1", "42", "secret", codeIPAddressReserved, http.StatusBadRequest},
+		// The test client connects from the loopback address.
+		{"reserved me", "city/me", "42", "secret", codeIPAddressReserved, http.StatusBadRequest},
+		{"not found", "city/1.1.1.1", "42", "secret", codeIPAddressNotFound, http.StatusNotFound},
+		{"permission", "insights/81.2.69.160", "42", "secret", codePermissionRequired, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, body := getWebService(t, ts.URL+webServicePrefix+test.path, test.id, test.key)
+			assert.Equal(t, test.status, resp.StatusCode)
+			assert.Equal(t,
+				"application/vnd.maxmind.com-error+json; charset=UTF-8; version=2.0",
+				resp.Header.Get("Content-Type"),
+			)
+			assert.Equal(t, test.code, body["code"])
+			assert.NotEmpty(t, body["error"])
+		})
+	}
+}
+
+func TestWebServiceDisabled(t *testing.T) {
+	_, ts := newTestServer(t, testDataDir+"GeoIP2-City-Test.mmdb")
+
+	resp, err := http.Get(ts.URL + "/geoip/v2.1/city/81.2.69.160")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAccountsFlag(t *testing.T) {
+	accounts := accountsFlag{}
+	require.NoError(t, accounts.Set("42:secret"))
+	require.NoError(t, accounts.Set("7:other:key"))
+	assert.Equal(t, accountsFlag{"42": "secret", "7": "other:key"}, accounts)
+	assert.Equal(t, "42,7", accounts.String())
+
+	assert.Error(t, accounts.Set("42"))
+	assert.Error(t, accounts.Set(":secret"))
+}
@@ -0,0 +1,76 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountryCode(t *testing.T) {
+	gb, err := ParseCountryCode("gb")
+	require.NoError(t, err)
+	assert.Equal(t, CountryCode("GB"), gb)
+	assert.Equal(t, "GBR", gb.Alpha3())
+	assert.Equal(t, 826, gb.Numeric())
+	assert.Equal(t, "United Kingdom", gb.Name())
+	assert.Equal(t, "\U0001F1EC\U0001F1E7", gb.Flag())
+
+	xk, err := ParseCountryCode("XK")
+	require.NoError(t, err)
+	assert.Equal(t, "Kosovo", xk.Name())
+	assert.Equal(t, 0, xk.Numeric())
+
+	for _, s := range []string{"", "G", "GBR", "ZZ", "EU"} {
+		_, err := ParseCountryCode(s)
+		assert.Equal(t, InvalidCodeError{Code: s, Kind: "country"}, err)
+	}
+
+	invalid := CountryCode("ZZ")
+	assert.False(t, invalid.IsValid())
+	assert.Empty(t, invalid.Alpha3())
+	assert.Zero(t, invalid.Numeric())
+	assert.Empty(t, invalid.Name())
+	assert.Empty(t, invalid.Flag())
+
+	// Every ISO 3166-1 code plus XK.
+	assert.Len(t, countries, 250)
+	alpha3 := map[string]bool{}
+	for code, info := range countries {
+		assert.Len(t, string(code), 2)
+		assert.Len(t, info.alpha3, 3)
+		assert.NotEmpty(t, info.name)
+		assert.False(t, alpha3[info.alpha3], info.alpha3)
+		alpha3[info.alpha3] = true
+	}
+}
+
+func TestContinentCode(t *testing.T) {
+	eu, err := ParseContinentCode("eu")
+	require.NoError(t, err)
+	assert.Equal(t, ContinentEurope, eu)
+	assert.Equal(t, "Europe", eu.Name())
+	assert.Equal(t, "North America", ContinentNorthAmerica.Name())
+
+	_, err = ParseContinentCode("XX")
+	assert.Equal(t, InvalidCodeError{Code: "XX", Kind: "continent"}, err)
+	assert.EqualError(t, err, `geoip2: "XX" is not a valid continent code`)
+	assert.False(t, ContinentCode("").IsValid())
+	assert.Empty(t, ContinentCode("XX").Name())
+}
+
+func TestCodesFromDatabase(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Country(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	continent := ContinentCode(record.Continent.Code)
+	country := CountryCode(record.Country.IsoCode)
+	assert.True(t, continent.IsValid())
+	assert.True(t, country.IsValid())
+	assert.Equal(t, record.Country.Names["en"], country.Name())
+	assert.Equal(t, record.Continent.Names["en"], continent.Name())
+}
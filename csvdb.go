@@ -0,0 +1,484 @@
+package geoip2
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CityReader is implemented by the types that provide City lookups, such as
+// Reader and CSVDatabase.
+type CityReader interface {
+	City(ipAddress net.IP) (*City, error)
+}
+
+// CountryReader is implemented by the types that provide Country lookups,
+// such as Reader and CSVDatabase.
+type CountryReader interface {
+	Country(ipAddress net.IP) (*Country, error)
+}
+
+// ASNReader is implemented by the types that provide ASN lookups, such as
+// Reader and CSVDatabase.
+type ASNReader interface {
+	ASN(ipAddress net.IP) (*ASN, error)
+}
+
+// CSVFiles holds the sources read by LoadCSV.
+type CSVFiles struct {
+	// BlocksIPv4 is the Blocks-IPv4 file. It may be nil.
+	BlocksIPv4 io.Reader
+	// BlocksIPv6 is the Blocks-IPv6 file. It may be nil.
+	BlocksIPv6 io.Reader
+	// Locations maps locales, such as "en" or "pt-BR", to the Locations
+	// file for that locale. It is not used for ASN databases.
+	Locations map[string]io.Reader
+}
+
+type csvBlock struct {
+	start                netip.Addr
+	end                  netip.Addr
+	postalCode           string
+	asOrganization       string
+	latitude             float64
+	longitude            float64
+	geoNameID            uint
+	registeredGeoNameID  uint
+	representedGeoNameID uint
+	asNumber             uint
+	accuracyRadius       uint16
+	hasLocation          bool
+	isAnonymousProxy     bool
+	isAnycast            bool
+	isSatelliteProvider  bool
+}
+
+// CSVDatabase is an in-memory database loaded from the CSV files of a
+// MaxMind City, Country, Enterprise or ASN database. It provides the City,
+// Country and ASN lookup methods of Reader so that code written against
+// CityReader, CountryReader or ASNReader works with either format.
+//
+// The CSV format does not contain every value of the binary format. In
+// particular, the GeoNameIDs of continents and subdivisions and the type of
+// represented countries are not available and are left empty. Of the
+// Enterprise-only data, only the autonomous system is loaded.
+type CSVDatabase struct {
+	locations    map[uint]*csvLocation
+	countries    map[string]uint
+	databaseType string
+	blocks       []csvBlock
+	dbType       databaseType
+}
+
+// LoadCSV reads the Blocks and Locations files of a City, Country,
+// Enterprise or ASN database, such as those written by ExportCSV or
+// distributed by MaxMind, into memory. The columns are identified by the
+// header of each file.
+func LoadCSV(files CSVFiles) (*CSVDatabase, error) {
+	db := &CSVDatabase{
+		locations: map[uint]*csvLocation{},
+		countries: map[string]uint{},
+	}
+
+	var header []string
+	for _, r := range []io.Reader{files.BlocksIPv4, files.BlocksIPv6} {
+		if r == nil {
+			continue
+		}
+		h, err := db.readBlocks(r)
+		if err != nil {
+			return nil, err
+		}
+		header = h
+	}
+	if header == nil {
+		return nil, errors.New("geoip2: no CSV blocks files were provided")
+	}
+
+	switch {
+	case slices.Contains(header, "autonomous_system_number") && slices.Contains(header, "geoname_id"):
+		db.dbType = isCity | isCountry | isASN
+		db.databaseType = "Enterprise CSV"
+	case slices.Contains(header, "autonomous_system_number"):
+		db.dbType = isASN
+		db.databaseType = "ASN CSV"
+	case slices.Contains(header, "latitude"):
+		db.dbType = isCity | isCountry
+		db.databaseType = "City CSV"
+	default:
+		db.dbType = isCity | isCountry
+		db.databaseType = "Country CSV"
+	}
+
+	if db.dbType&isCity != 0 {
+		for locale, r := range files.Locations {
+			if err := db.readLocations(locale, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	slices.SortFunc(db.blocks, func(a, b csvBlock) int {
+		return a.start.Compare(b.start)
+	})
+	return db, nil
+}
+
+// LoadCSVFiles reads the CSV files of a database from dir using LoadCSV.
+// The files are expected to be named as MaxMind names them, with prefix
+// being, e.g., "GeoLite2-City" for GeoLite2-City-Blocks-IPv4.csv,
+// GeoLite2-City-Blocks-IPv6.csv and GeoLite2-City-Locations-en.csv. Every
+// Locations file found for the prefix is loaded.
+func LoadCSVFiles(dir, prefix string) (db *CSVDatabase, err error) {
+	var files CSVFiles
+	var opened []*os.File
+	defer func() {
+		for _, f := range opened {
+			err = errors.Join(err, f.Close())
+		}
+	}()
+	open := func(name string) (io.Reader, error) {
+		f, err := os.Open(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		opened = append(opened, f)
+		return f, nil
+	}
+
+	if files.BlocksIPv4, err = open(prefix + "-Blocks-IPv4.csv"); err != nil {
+		return nil, err
+	}
+	if files.BlocksIPv6, err = open(prefix + "-Blocks-IPv6.csv"); err != nil {
+		return nil, err
+	}
+
+	locationFiles, err := filepath.Glob(filepath.Join(dir, prefix+"-Locations-*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	files.Locations = map[string]io.Reader{}
+	for _, path := range locationFiles {
+		name := filepath.Base(path)
+		locale := strings.TrimSuffix(strings.TrimPrefix(name, prefix+"-Locations-"), ".csv")
+		if files.Locations[locale], err = open(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return LoadCSV(files)
+}
+
+// City takes an IP address as a net.IP struct and returns a City struct
+// and/or an error.
+func (db *CSVDatabase) City(ipAddress net.IP) (*City, error) {
+	if isCity&db.dbType == 0 {
+		return nil, InvalidMethodError{"City", db.databaseType}
+	}
+	var city City
+	block := db.find(ipAddress)
+	if block == nil {
+		return &city, nil
+	}
+
+	if loc := db.locations[block.geoNameID]; loc != nil {
+		if len(loc.cityNames) > 0 {
+			city.City.GeoNameID = block.geoNameID
+			city.City.Names = cloneNames(loc.cityNames)
+		}
+		city.Continent.Code = loc.continentCode
+		city.Continent.Names = cloneNames(loc.continentNames)
+		city.Country.GeoNameID = db.countries[loc.countryIsoCode]
+		city.Country.IsoCode = loc.countryIsoCode
+		city.Country.Names = cloneNames(loc.countryNames)
+		city.Country.IsInEuropeanUnion = loc.isInEU
+		city.Location.MetroCode = loc.metroCode
+		city.Location.TimeZone = loc.timeZone
+		for _, sub := range []struct {
+			names map[string]string
+			code  string
+		}{
+			{loc.subdivision1Names, loc.subdivision1Code},
+			{loc.subdivision2Names, loc.subdivision2Code},
+		} {
+			if sub.code == "" && len(sub.names) == 0 {
+				continue
+			}
+			city.Subdivisions = append(city.Subdivisions, Subdivision{Names: cloneNames(sub.names), IsoCode: sub.code})
+		}
+	}
+	if loc := db.locations[block.registeredGeoNameID]; loc != nil {
+		city.RegisteredCountry.GeoNameID = block.registeredGeoNameID
+		city.RegisteredCountry.IsoCode = loc.countryIsoCode
+		city.RegisteredCountry.Names = cloneNames(loc.countryNames)
+		city.RegisteredCountry.IsInEuropeanUnion = loc.isInEU
+	}
+	if loc := db.locations[block.representedGeoNameID]; loc != nil {
+		city.RepresentedCountry.GeoNameID = block.representedGeoNameID
+		city.RepresentedCountry.IsoCode = loc.countryIsoCode
+		city.RepresentedCountry.Names = cloneNames(loc.countryNames)
+		city.RepresentedCountry.IsInEuropeanUnion = loc.isInEU
+	}
+
+	city.Postal.Code = block.postalCode
+	if block.hasLocation {
+		city.Location.Latitude = block.latitude
+		city.Location.Longitude = block.longitude
+	}
+	city.Location.AccuracyRadius = block.accuracyRadius
+	city.Traits.IsAnonymousProxy = block.isAnonymousProxy
+	city.Traits.IsAnycast = block.isAnycast
+	city.Traits.IsSatelliteProvider = block.isSatelliteProvider
+	return &city, nil
+}
+
+// Country takes an IP address as a net.IP struct and returns a Country
+// struct and/or an error.
+func (db *CSVDatabase) Country(ipAddress net.IP) (*Country, error) {
+	if isCountry&db.dbType == 0 {
+		return nil, InvalidMethodError{"Country", db.databaseType}
+	}
+	city, err := db.City(ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var country Country
+	country.Continent = city.Continent
+	country.Country = city.Country
+	country.RegisteredCountry = city.RegisteredCountry
+	country.RepresentedCountry = city.RepresentedCountry
+	country.Traits = city.Traits
+	return &country, nil
+}
+
+// ASN takes an IP address as a net.IP struct and returns an ASN struct
+// and/or an error.
+func (db *CSVDatabase) ASN(ipAddress net.IP) (*ASN, error) {
+	if isASN&db.dbType == 0 {
+		return nil, InvalidMethodError{"ASN", db.databaseType}
+	}
+	var val ASN
+	if block := db.find(ipAddress); block != nil {
+		val.AutonomousSystemNumber = block.asNumber
+		val.AutonomousSystemOrganization = block.asOrganization
+	}
+	return &val, nil
+}
+
+func (db *CSVDatabase) find(ipAddress net.IP) *csvBlock {
+	ip := ipAddress.To16()
+	if ip == nil {
+		return nil
+	}
+	var addr netip.Addr
+	if ip4 := ipAddress.To4(); ip4 != nil {
+		var b [16]byte
+		copy(b[12:], ip4)
+		addr = netip.AddrFrom16(b)
+	} else {
+		addr = netip.AddrFrom16([16]byte(ip))
+	}
+
+	i, found := slices.BinarySearchFunc(db.blocks, addr, func(b csvBlock, a netip.Addr) int {
+		return b.start.Compare(a)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 || db.blocks[i].end.Less(addr) {
+		return nil
+	}
+	return &db.blocks[i]
+}
+
+// cloneNames returns a copy of names so that callers cannot modify the
+// database, or nil if there are no names, matching the records returned by
+// Reader.
+func cloneNames(names map[string]string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	clone := make(map[string]string, len(names))
+	for k, v := range names {
+		clone[k] = v
+	}
+	return clone
+}
+
+// csvRows reads a CSV file with a header and calls fn with a function
+// returning the value of a named column for each row.
+func csvRows(r io.Reader, fn func(get func(column string) string) error) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("geoip2: reading CSV header: %w", err)
+	}
+	header = slices.Clone(header)
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimPrefix(name, "\ufeff")] = i
+	}
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return header, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("geoip2: reading CSV: %w", err)
+		}
+		err = fn(func(column string) string {
+			if i, ok := columns[column]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		})
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("geoip2: CSV line %d: %w", line, err)
+		}
+	}
+}
+
+func (db *CSVDatabase) readBlocks(r io.Reader) ([]string, error) {
+	return csvRows(r, func(get func(string) string) error {
+		_, network, err := net.ParseCIDR(get("network"))
+		if err != nil {
+			return err
+		}
+		prefix := ipNetToPrefix(network)
+
+		p := csvParser{get: get}
+		block := csvBlock{
+			start:                prefix.Addr(),
+			end:                  lastAddr(prefix),
+			geoNameID:            p.uint("geoname_id"),
+			registeredGeoNameID:  p.uint("registered_country_geoname_id"),
+			representedGeoNameID: p.uint("represented_country_geoname_id"),
+			isAnonymousProxy:     p.bool("is_anonymous_proxy"),
+			isSatelliteProvider:  p.bool("is_satellite_provider"),
+			isAnycast:            p.bool("is_anycast"),
+			postalCode:           get("postal_code"),
+			hasLocation:          get("latitude") != "" || get("longitude") != "",
+			latitude:             p.float("latitude"),
+			longitude:            p.float("longitude"),
+			accuracyRadius:       uint16(p.uint("accuracy_radius")),
+			asNumber:             p.uint("autonomous_system_number"),
+			asOrganization:       get("autonomous_system_organization"),
+		}
+		if p.err != nil {
+			return p.err
+		}
+		db.blocks = append(db.blocks, block)
+		return nil
+	})
+}
+
+func (db *CSVDatabase) readLocations(locale string, r io.Reader) error {
+	_, err := csvRows(r, func(get func(string) string) error {
+		p := csvParser{get: get}
+		id := p.uint("geoname_id")
+		metroCode := p.uint("metro_code")
+		isInEU := p.bool("is_in_european_union")
+		if p.err != nil {
+			return p.err
+		}
+
+		loc, ok := db.locations[id]
+		if !ok {
+			loc = &csvLocation{
+				continentNames:    map[string]string{},
+				countryNames:      map[string]string{},
+				subdivision1Names: map[string]string{},
+				subdivision2Names: map[string]string{},
+				cityNames:         map[string]string{},
+				continentCode:     get("continent_code"),
+				countryIsoCode:    get("country_iso_code"),
+				subdivision1Code:  get("subdivision_1_iso_code"),
+				subdivision2Code:  get("subdivision_2_iso_code"),
+				timeZone:          get("time_zone"),
+				metroCode:         metroCode,
+				isInEU:            isInEU,
+			}
+			db.locations[id] = loc
+		}
+		for _, field := range []struct {
+			names  map[string]string
+			column string
+		}{
+			{loc.continentNames, "continent_name"},
+			{loc.countryNames, "country_name"},
+			{loc.subdivision1Names, "subdivision_1_name"},
+			{loc.subdivision2Names, "subdivision_2_name"},
+			{loc.cityNames, "city_name"},
+		} {
+			if v := get(field.column); v != "" {
+				field.names[locale] = v
+			}
+		}
+
+		if get("city_name") == "" && get("subdivision_1_iso_code") == "" &&
+			get("subdivision_1_name") == "" && loc.countryIsoCode != "" {
+			db.countries[loc.countryIsoCode] = id
+		}
+		return nil
+	})
+	return err
+}
+
+// csvParser parses numeric CSV columns, remembering the first error.
+// Empty columns are parsed as zero values.
+type csvParser struct {
+	err error
+	get func(string) string
+}
+
+func (p *csvParser) uint(column string) uint {
+	v := p.get(column)
+	if v == "" || p.err != nil {
+		return 0
+	}
+	n, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q: %w", column, v, err)
+	}
+	return uint(n)
+}
+
+func (p *csvParser) float(column string) float64 {
+	v := p.get(column)
+	if v == "" || p.err != nil {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q: %w", column, v, err)
+	}
+	return f
+}
+
+func (p *csvParser) bool(column string) bool {
+	v := p.get(column)
+	if v == "" || p.err != nil {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q: %w", column, v, err)
+	}
+	return b
+}
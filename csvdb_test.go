@@ -0,0 +1,175 @@
+package geoip2
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ CityReader    = (*Reader)(nil)
+	_ CountryReader = (*Reader)(nil)
+	_ ASNReader     = (*Reader)(nil)
+	_ CityReader    = (*CSVDatabase)(nil)
+	_ CountryReader = (*CSVDatabase)(nil)
+	_ ASNReader     = (*CSVDatabase)(nil)
+)
+
+func TestLoadCSVRoundTrip(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var v4, v6, en, ru bytes.Buffer
+	require.NoError(t, ExportCSV(reader, CSVWriters{
+		BlocksIPv4: &v4,
+		BlocksIPv6: &v6,
+		Locations:  map[string]io.Writer{"en": &en, "ru": &ru},
+	}))
+
+	db, err := LoadCSV(CSVFiles{
+		BlocksIPv4: &v4,
+		BlocksIPv6: &v6,
+		Locations:  map[string]io.Reader{"en": &en, "ru": &ru},
+	})
+	require.NoError(t, err)
+
+	for _, ip := range []string{"81.2.69.160", "216.160.83.56", "2001:218::1", "10.0.0.1"} {
+		t.Run(ip, func(t *testing.T) {
+			expected, err := reader.City(net.ParseIP(ip))
+			require.NoError(t, err)
+
+			record, err := db.City(net.ParseIP(ip))
+			require.NoError(t, err)
+
+			assert.Equal(t, expected.City.GeoNameID, record.City.GeoNameID)
+			assert.Equal(t, expected.City.Names["en"], record.City.Names["en"])
+			assert.Equal(t, expected.City.Names["ru"], record.City.Names["ru"])
+			assert.Equal(t, expected.Continent.Code, record.Continent.Code)
+			assert.Equal(t, expected.Country.IsoCode, record.Country.IsoCode)
+			assert.Equal(t, expected.Country.Names["en"], record.Country.Names["en"])
+			assert.Equal(t, expected.RegisteredCountry.GeoNameID, record.RegisteredCountry.GeoNameID)
+			assert.Equal(t, expected.RegisteredCountry.IsoCode, record.RegisteredCountry.IsoCode)
+			assert.Equal(t, expected.Location, record.Location)
+			assert.Equal(t, expected.Postal, record.Postal)
+			assert.Equal(t, expected.Traits, record.Traits)
+			require.Len(t, record.Subdivisions, min(len(expected.Subdivisions), 2))
+			for i := range record.Subdivisions {
+				assert.Equal(t, expected.Subdivisions[i].IsoCode, record.Subdivisions[i].IsoCode)
+			}
+
+			country, err := db.Country(net.ParseIP(ip))
+			require.NoError(t, err)
+			assert.Equal(t, expected.Country.IsoCode, country.Country.IsoCode)
+		})
+	}
+
+	_, err = db.ASN(net.ParseIP("81.2.69.160"))
+	assert.Equal(t, InvalidMethodError{"ASN", "City CSV"}, err)
+}
+
+func TestLoadCSVEnterpriseRoundTrip(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Enterprise-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var v4, v6, en bytes.Buffer
+	require.NoError(t, ExportCSV(reader, CSVWriters{
+		BlocksIPv4: &v4,
+		BlocksIPv6: &v6,
+		Locations:  map[string]io.Writer{"en": &en},
+	}))
+
+	db, err := LoadCSV(CSVFiles{
+		BlocksIPv4: &v4,
+		BlocksIPv6: &v6,
+		Locations:  map[string]io.Reader{"en": &en},
+	})
+	require.NoError(t, err)
+
+	for _, ip := range []string{"81.2.69.160", "74.209.24.0", "2001:218::1"} {
+		t.Run(ip, func(t *testing.T) {
+			expected, err := reader.Enterprise(net.ParseIP(ip))
+			require.NoError(t, err)
+
+			city, err := db.City(net.ParseIP(ip))
+			require.NoError(t, err)
+			assert.Equal(t, expected.City.Names["en"], city.City.Names["en"])
+			assert.Equal(t, expected.Country.IsoCode, city.Country.IsoCode)
+			assert.Equal(t, expected.Location, city.Location)
+
+			country, err := db.Country(net.ParseIP(ip))
+			require.NoError(t, err)
+			assert.Equal(t, expected.Country.IsoCode, country.Country.IsoCode)
+
+			asn, err := db.ASN(net.ParseIP(ip))
+			require.NoError(t, err)
+			assert.Equal(t, expected.Traits.AutonomousSystemNumber, asn.AutonomousSystemNumber)
+			assert.Equal(t, expected.Traits.AutonomousSystemOrganization, asn.AutonomousSystemOrganization)
+		})
+	}
+}
+
+func TestLoadCSVASN(t *testing.T) {
+	db, err := LoadCSV(CSVFiles{
+		BlocksIPv4: strings.NewReader(
+			"network,autonomous_system_number,autonomous_system_organization\n" +
+				"1.128.0.0/11,1221,Telstra Pty Ltd\n",
+		),
+	})
+	require.NoError(t, err)
+
+	record, err := db.ASN(net.ParseIP("1.128.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, uint(1221), record.AutonomousSystemNumber)
+	assert.Equal(t, "Telstra Pty Ltd", record.AutonomousSystemOrganization)
+
+	record, err = db.ASN(net.ParseIP("1.160.0.0"))
+	require.NoError(t, err)
+	assert.Zero(t, record.AutonomousSystemNumber)
+
+	_, err = db.City(net.ParseIP("1.128.0.0"))
+	assert.Equal(t, InvalidMethodError{"City", "ASN CSV"}, err)
+}
+
+func TestLoadCSVFiles(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, ExportCSVFiles(reader, dir, "en", "de"))
+
+	db, err := LoadCSVFiles(dir, "GeoIP2-Country")
+	require.NoError(t, err)
+
+	record, err := db.Country(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Equal(t, "GB", record.Country.IsoCode)
+	assert.Equal(t, uint(2635167), record.Country.GeoNameID)
+	assert.Equal(t, "United Kingdom", record.Country.Names["en"])
+	assert.Equal(t, "Vereinigtes Königreich", record.Country.Names["de"])
+}
+
+func TestLoadCSVInvalid(t *testing.T) {
+	_, err := LoadCSV(CSVFiles{})
+	require.Error(t, err)
+
+	_, err = LoadCSV(CSVFiles{
+		BlocksIPv4: strings.NewReader(
+			"network,autonomous_system_number,autonomous_system_organization\n" +
+				"1.128.0.0/11,abc,Telstra Pty Ltd\n",
+		),
+	})
+	assert.EqualError(
+		t,
+		err,
+		`geoip2: CSV line 2: invalid autonomous_system_number "abc": `+
+			`strconv.ParseUint: parsing "abc": invalid syntax`,
+	)
+}
@@ -0,0 +1,468 @@
+package geoip2
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// DiffKind describes how a network differs between two databases.
+type DiffKind int
+
+const (
+	// NetworkAdded indicates that the network only has data in the new
+	// database.
+	NetworkAdded DiffKind = iota + 1
+	// NetworkRemoved indicates that the network only has data in the old
+	// database.
+	NetworkRemoved
+	// NetworkChanged indicates that one or more of the compared fields
+	// differ between the databases.
+	NetworkChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case NetworkAdded:
+		return "added"
+	case NetworkRemoved:
+		return "removed"
+	case NetworkChanged:
+		return "changed"
+	default:
+		return fmt.Sprintf("DiffKind(%d)", int(k))
+	}
+}
+
+// DiffField is a bit set of the fields compared by Diff.
+type DiffField int
+
+const (
+	// DiffCountry compares Country.IsoCode.
+	DiffCountry DiffField = 1 << iota
+	// DiffCity compares City.GeoNameID.
+	DiffCity
+	// DiffASN compares the autonomous system number.
+	DiffASN
+	// DiffAnonymizer compares the anonymizer flags, such as IsAnonymousVPN
+	// or IsTorExitNode.
+	DiffAnonymizer
+
+	diffAllFields = DiffCountry | DiffCity | DiffASN | DiffAnonymizer
+)
+
+func (f DiffField) String() string {
+	var names []string
+	for _, field := range []struct {
+		name string
+		flag DiffField
+	}{
+		{"country", DiffCountry},
+		{"city", DiffCity},
+		{"asn", DiffASN},
+		{"anonymizer", DiffAnonymizer},
+	} {
+		if f&field.flag != 0 {
+			names = append(names, field.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// DiffRecord holds the values that Diff compares for a network. Fields that
+// the database type does not contain are left empty. For instance, a City
+// database has no AutonomousSystemNumber.
+type DiffRecord struct {
+	CountryIsoCode         string
+	CityName               string
+	CityGeoNameID          uint
+	AutonomousSystemNumber uint
+	IsAnonymous            bool
+	IsAnonymousProxy       bool
+	IsAnonymousVPN         bool
+	IsHostingProvider      bool
+	IsPublicProxy          bool
+	IsResidentialProxy     bool
+	IsTorExitNode          bool
+}
+
+func (r DiffRecord) anonymizer() [7]bool {
+	return [7]bool{
+		r.IsAnonymous,
+		r.IsAnonymousProxy,
+		r.IsAnonymousVPN,
+		r.IsHostingProvider,
+		r.IsPublicProxy,
+		r.IsResidentialProxy,
+		r.IsTorExitNode,
+	}
+}
+
+// changes returns the fields, out of those in fields, that differ between
+// r and other.
+func (r DiffRecord) changes(other DiffRecord, fields DiffField) DiffField {
+	var changed DiffField
+	if r.CountryIsoCode != other.CountryIsoCode {
+		changed |= DiffCountry
+	}
+	if r.CityGeoNameID != other.CityGeoNameID {
+		changed |= DiffCity
+	}
+	if r.AutonomousSystemNumber != other.AutonomousSystemNumber {
+		changed |= DiffASN
+	}
+	if r.anonymizer() != other.anonymizer() {
+		changed |= DiffAnonymizer
+	}
+	return changed & fields
+}
+
+// DiffEntry describes a network that differs between two databases.
+type DiffEntry struct {
+	Network *net.IPNet
+	// Old is the record in the old database. It is empty for added
+	// networks.
+	Old DiffRecord
+	// New is the record in the new database. It is empty for removed
+	// networks.
+	New DiffRecord
+	// Kind is how the network differs.
+	Kind DiffKind
+	// Changed is the set of fields that differ. It is only set for changed
+	// networks.
+	Changed DiffField
+}
+
+// DiffSummary contains statistics about the entries of a DiffReport.
+type DiffSummary struct {
+	Added   int
+	Removed int
+	Changed int
+	// The following count the changed networks by field. A network with
+	// several changed fields is counted once for each of them.
+	CountryChanged    int
+	CityChanged       int
+	ASNChanged        int
+	AnonymizerChanged int
+}
+
+// DiffReport is the result of Diff.
+type DiffReport struct {
+	// Entries are the networks that differ, in ascending order.
+	Entries []DiffEntry
+	Summary DiffSummary
+}
+
+// DiffOptions configures Diff.
+type DiffOptions struct {
+	// Countries restricts the report to networks whose old or new
+	// Country.IsoCode is in the list.
+	Countries []string
+	// ASNs restricts the report to networks whose old or new autonomous
+	// system number is in the list.
+	ASNs []uint
+	// Fields is the set of fields to compare. If it is zero, all fields are
+	// compared.
+	Fields DiffField
+}
+
+// Diff walks the networks of two databases of the same type, for instance
+// two releases of a City database, and reports the networks that were
+// added, removed or whose country, city, ASN or anonymizer flags changed.
+//
+// Networks are reported at the granularity of the more specific of the two
+// databases. Networks whose compared fields did not change are omitted even
+// if other data, such as a city name, did.
+func Diff(oldDB, newDB *Reader, options DiffOptions) (*DiffReport, error) {
+	if oldDB.databaseType != newDB.databaseType {
+		return nil, fmt.Errorf(
+			"geoip2: cannot diff a %s database against a %s database",
+			oldDB.Metadata().DatabaseType,
+			newDB.Metadata().DatabaseType,
+		)
+	}
+
+	fields := options.Fields
+	if fields == 0 {
+		fields = diffAllFields
+	}
+
+	report := &DiffReport{}
+	err := diffSegments(
+		newDiffIterator(oldDB.Networks()),
+		newDiffIterator(newDB.Networks()),
+		func(start, end netip.Addr, oldRecord, newRecord *DiffRecord) {
+			entry := DiffEntry{}
+			switch {
+			case oldRecord == nil:
+				entry.Kind = NetworkAdded
+				entry.New = *newRecord
+			case newRecord == nil:
+				entry.Kind = NetworkRemoved
+				entry.Old = *oldRecord
+			default:
+				entry.Changed = oldRecord.changes(*newRecord, fields)
+				if entry.Changed == 0 {
+					return
+				}
+				entry.Kind = NetworkChanged
+				entry.Old = *oldRecord
+				entry.New = *newRecord
+			}
+			if !options.matches(entry) {
+				return
+			}
+			for _, prefix := range rangeToPrefixes(start, end) {
+				entry.Network = prefixToIPNet(prefix)
+				report.add(entry)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (o DiffOptions) matches(entry DiffEntry) bool {
+	if len(o.Countries) > 0 &&
+		!slices.Contains(o.Countries, entry.Old.CountryIsoCode) &&
+		!slices.Contains(o.Countries, entry.New.CountryIsoCode) {
+		return false
+	}
+	if len(o.ASNs) > 0 &&
+		!slices.Contains(o.ASNs, entry.Old.AutonomousSystemNumber) &&
+		!slices.Contains(o.ASNs, entry.New.AutonomousSystemNumber) {
+		return false
+	}
+	return true
+}
+
+func (r *DiffReport) add(entry DiffEntry) {
+	r.Entries = append(r.Entries, entry)
+	switch entry.Kind {
+	case NetworkAdded:
+		r.Summary.Added++
+	case NetworkRemoved:
+		r.Summary.Removed++
+	case NetworkChanged:
+		r.Summary.Changed++
+	}
+	if entry.Changed&DiffCountry != 0 {
+		r.Summary.CountryChanged++
+	}
+	if entry.Changed&DiffCity != 0 {
+		r.Summary.CityChanged++
+	}
+	if entry.Changed&DiffASN != 0 {
+		r.Summary.ASNChanged++
+	}
+	if entry.Changed&DiffAnonymizer != 0 {
+		r.Summary.AnonymizerChanged++
+	}
+}
+
+// diffData is decoded from every network. It covers the layouts of all of
+// the supported database types.
+type diffData struct {
+	City struct {
+		Names     map[string]string `maxminddb:"names"`
+		GeoNameID uint              `maxminddb:"geoname_id"`
+	} `maxminddb:"city"`
+	Country struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Traits struct {
+		AutonomousSystemNumber uint `maxminddb:"autonomous_system_number"`
+		IsAnonymousProxy       bool `maxminddb:"is_anonymous_proxy"`
+	} `maxminddb:"traits"`
+	AutonomousSystemNumber uint `maxminddb:"autonomous_system_number"`
+	IsAnonymous            bool `maxminddb:"is_anonymous"`
+	IsAnonymousVPN         bool `maxminddb:"is_anonymous_vpn"`
+	IsHostingProvider      bool `maxminddb:"is_hosting_provider"`
+	IsPublicProxy          bool `maxminddb:"is_public_proxy"`
+	IsResidentialProxy     bool `maxminddb:"is_residential_proxy"`
+	IsTorExitNode          bool `maxminddb:"is_tor_exit_node"`
+}
+
+func (d diffData) record() DiffRecord {
+	asn := d.AutonomousSystemNumber
+	if asn == 0 {
+		asn = d.Traits.AutonomousSystemNumber
+	}
+	return DiffRecord{
+		CountryIsoCode:         d.Country.IsoCode,
+		CityName:               d.City.Names["en"],
+		CityGeoNameID:          d.City.GeoNameID,
+		AutonomousSystemNumber: asn,
+		IsAnonymous:            d.IsAnonymous,
+		IsAnonymousProxy:       d.Traits.IsAnonymousProxy,
+		IsAnonymousVPN:         d.IsAnonymousVPN,
+		IsHostingProvider:      d.IsHostingProvider,
+		IsPublicProxy:          d.IsPublicProxy,
+		IsResidentialProxy:     d.IsResidentialProxy,
+		IsTorExitNode:          d.IsTorExitNode,
+	}
+}
+
+// diffSegment is an inclusive range of addresses sharing a record.
+type diffSegment struct {
+	start  netip.Addr
+	end    netip.Addr
+	record DiffRecord
+}
+
+// diffIterator returns the next segment, false when there are no more
+// segments, or an error.
+type diffIterator func() (diffSegment, bool, error)
+
+func newDiffIterator(networks *Networks) diffIterator {
+	return func() (diffSegment, bool, error) {
+		if !networks.Next() {
+			return diffSegment{}, false, networks.Err()
+		}
+		var data diffData
+		network, err := networks.Network(&data)
+		if err != nil {
+			return diffSegment{}, false, err
+		}
+		prefix := ipNetToPrefix(network)
+		return diffSegment{
+			start:  prefix.Addr(),
+			end:    lastAddr(prefix),
+			record: data.record(),
+		}, true, nil
+	}
+}
+
+// diffSegments merges two ascending streams of non-overlapping segments and
+// calls emit for every range in which at least one of them has data and
+// neither changes records. oldRecord or newRecord is nil when the
+// respective stream has no data for the range.
+func diffSegments(
+	oldNext, newNext diffIterator,
+	emit func(start, end netip.Addr, oldRecord, newRecord *DiffRecord),
+) error {
+	a, aOK, err := oldNext()
+	if err != nil {
+		return err
+	}
+	b, bOK, err := newNext()
+	if err != nil {
+		return err
+	}
+
+	for aOK || bOK {
+		switch {
+		case !bOK || (aOK && a.end.Less(b.start)):
+			emit(a.start, a.end, &a.record, nil)
+			a, aOK, err = oldNext()
+		case !aOK || b.end.Less(a.start):
+			emit(b.start, b.end, nil, &b.record)
+			b, bOK, err = newNext()
+		case a.start.Less(b.start):
+			emit(a.start, b.start.Prev(), &a.record, nil)
+			a.start = b.start
+		case b.start.Less(a.start):
+			emit(b.start, a.start.Prev(), nil, &b.record)
+			b.start = a.start
+		default:
+			end := a.end
+			if b.end.Less(end) {
+				end = b.end
+			}
+			emit(a.start, end, &a.record, &b.record)
+			if a.end == end {
+				a, aOK, err = oldNext()
+			} else {
+				a.start = end.Next()
+			}
+			if err != nil {
+				return err
+			}
+			if b.end == end {
+				b, bOK, err = newNext()
+			} else {
+				b.start = end.Next()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ipNetToPrefix converts network to a prefix in the 128-bit address space.
+// IPv4 networks are placed in ::/96, matching the layout of the IPv4
+// subtree in an IPv6 database, so that addresses from either family sort
+// consistently.
+func ipNetToPrefix(network *net.IPNet) netip.Prefix {
+	ones, _ := network.Mask.Size()
+	if ip4 := network.IP.To4(); ip4 != nil && len(network.IP) == net.IPv4len {
+		var b [16]byte
+		copy(b[12:], ip4)
+		return netip.PrefixFrom(netip.AddrFrom16(b), ones+96)
+	}
+	addr, _ := netip.AddrFromSlice(network.IP)
+	return netip.PrefixFrom(addr, ones)
+}
+
+// prefixToIPNet reverses ipNetToPrefix.
+func prefixToIPNet(prefix netip.Prefix) *net.IPNet {
+	b := prefix.Addr().As16()
+	if prefix.Bits() >= 96 && [12]byte(b[:12]) == [12]byte{} {
+		return &net.IPNet{
+			IP:   net.IP(b[12:]),
+			Mask: net.CIDRMask(prefix.Bits()-96, 32),
+		}
+	}
+	return &net.IPNet{
+		IP:   net.IP(b[:]),
+		Mask: net.CIDRMask(prefix.Bits(), 128),
+	}
+}
+
+// lastAddr returns the highest address in prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().As16()
+	bits := prefix.Bits()
+	for i := range b {
+		switch {
+		case bits >= 8:
+			bits -= 8
+		case bits > 0:
+			b[i] |= 0xff >> bits
+			bits = 0
+		default:
+			b[i] = 0xff
+		}
+	}
+	return netip.AddrFrom16(b)
+}
+
+// rangeToPrefixes returns the smallest list of prefixes exactly covering
+// the inclusive range from start to end.
+func rangeToPrefixes(start, end netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for !end.Less(start) {
+		bits := 0
+		for ; bits < 128; bits++ {
+			prefix := netip.PrefixFrom(start, bits)
+			if prefix.Masked().Addr() == start && !end.Less(lastAddr(prefix)) {
+				break
+			}
+		}
+		prefix := netip.PrefixFrom(start, bits)
+		prefixes = append(prefixes, prefix)
+
+		last := lastAddr(prefix)
+		if !last.Next().IsValid() {
+			break
+		}
+		start = last.Next()
+	}
+	return prefixes
+}
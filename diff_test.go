@@ -0,0 +1,147 @@
+package geoip2
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sliceDiffIterator(t *testing.T, segments map[string]DiffRecord, order ...string) diffIterator {
+	t.Helper()
+
+	var prefixes []netip.Prefix
+	for _, s := range order {
+		prefixes = append(prefixes, ipNetToPrefix(mustParseCIDR(t, s)))
+	}
+	return func() (diffSegment, bool, error) {
+		if len(prefixes) == 0 {
+			return diffSegment{}, false, nil
+		}
+		prefix := prefixes[0]
+		prefixes = prefixes[1:]
+		return diffSegment{
+			start:  prefix.Addr(),
+			end:    lastAddr(prefix),
+			record: segments[prefixToIPNet(prefix).String()],
+		}, true, nil
+	}
+}
+
+func mustParseCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+
+	_, network, err := net.ParseCIDR(s)
+	require.NoError(t, err)
+	return network
+}
+
+func TestDiffSegments(t *testing.T) {
+	gb := DiffRecord{CountryIsoCode: "GB"}
+	fr := DiffRecord{CountryIsoCode: "FR"}
+
+	oldNext := sliceDiffIterator(t, map[string]DiffRecord{
+		"1.0.0.0/24": gb,
+		"2.0.0.0/23": gb,
+		"3.0.0.0/24": gb,
+	}, "1.0.0.0/24", "2.0.0.0/23", "3.0.0.0/24")
+	newNext := sliceDiffIterator(t, map[string]DiffRecord{
+		"2.0.1.0/24": fr,
+		"3.0.0.0/24": gb,
+		"4.0.0.0/24": fr,
+	}, "2.0.1.0/24", "3.0.0.0/24", "4.0.0.0/24")
+
+	type emitted struct {
+		network string
+		kind    DiffKind
+	}
+	var got []emitted
+	err := diffSegments(oldNext, newNext, func(start, end netip.Addr, oldRecord, newRecord *DiffRecord) {
+		kind := NetworkChanged
+		switch {
+		case oldRecord == nil:
+			kind = NetworkAdded
+		case newRecord == nil:
+			kind = NetworkRemoved
+		case oldRecord.changes(*newRecord, diffAllFields) == 0:
+			return
+		}
+		for _, prefix := range rangeToPrefixes(start, end) {
+			got = append(got, emitted{prefixToIPNet(prefix).String(), kind})
+		}
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []emitted{
+		{"1.0.0.0/24", NetworkRemoved},
+		{"2.0.0.0/24", NetworkRemoved},
+		{"2.0.1.0/24", NetworkChanged},
+		{"4.0.0.0/24", NetworkAdded},
+	}, got)
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	var got []string
+	for _, prefix := range rangeToPrefixes(
+		netip.MustParseAddr("::1.0.0.1"),
+		netip.MustParseAddr("::1.0.0.8"),
+	) {
+		got = append(got, prefixToIPNet(prefix).String())
+	}
+	assert.Equal(t, []string{"1.0.0.1/32", "1.0.0.2/31", "1.0.0.4/30", "1.0.0.8/32"}, got)
+
+	all := rangeToPrefixes(netip.IPv6Unspecified(), lastAddr(netip.MustParsePrefix("::/0")))
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("::/0")}, all)
+}
+
+func TestDiff(t *testing.T) {
+	oldDB, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer oldDB.Close()
+
+	newDB, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer newDB.Close()
+
+	report, err := Diff(oldDB, newDB, DiffOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Entries)
+	assert.Equal(t, DiffSummary{}, report.Summary)
+
+	asnDB, err := Open("test-data/test-data/GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer asnDB.Close()
+
+	_, err = Diff(asnDB, newDB, DiffOptions{})
+	require.EqualError(
+		t,
+		err,
+		"geoip2: cannot diff a GeoLite2-ASN database against a GeoIP2-City database",
+	)
+}
+
+func TestDiffFilters(t *testing.T) {
+	// The Country database has no city data, so every network with a city in
+	// the City database differs.
+	oldDB, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer oldDB.Close()
+
+	newDB, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer newDB.Close()
+
+	report, err := Diff(oldDB, newDB, DiffOptions{Countries: []string{"GB"}})
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Entries)
+	assert.Positive(t, report.Summary.CityChanged)
+	for _, entry := range report.Entries {
+		assert.True(t, entry.Old.CountryIsoCode == "GB" || entry.New.CountryIsoCode == "GB")
+	}
+
+	report, err = Diff(oldDB, newDB, DiffOptions{Fields: DiffCountry})
+	require.NoError(t, err)
+	assert.Zero(t, report.Summary.CityChanged)
+}
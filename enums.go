@@ -0,0 +1,181 @@
+package geoip2
+
+import "strings"
+
+// ConnectionKind is the type of connection of a network, as found in the
+// GeoIP2 Connection-Type and Enterprise databases. The ConnectionType fields
+// of the records are plain strings; convert them to use the constants and
+// predicates, e.g.:
+//
+//	switch geoip2.ConnectionKind(record.ConnectionType) {
+//	case geoip2.ConnectionCellular:
+//		// ...
+//	}
+//
+// A conversion keeps values added to the databases after this package was
+// released rather than losing them. Use IsKnown to check for one, or
+// ParseConnectionKind to map it to ConnectionUnknown.
+type ConnectionKind string
+
+// The connection types used in the databases. ConnectionUnknown, the zero
+// value, is used for networks without a connection type.
+const (
+	ConnectionUnknown   ConnectionKind = ""
+	ConnectionDialup    ConnectionKind = "Dialup"
+	ConnectionCableDSL  ConnectionKind = "Cable/DSL"
+	ConnectionCorporate ConnectionKind = "Corporate"
+	ConnectionCellular  ConnectionKind = "Cellular"
+	ConnectionSatellite ConnectionKind = "Satellite"
+)
+
+var connectionKinds = []ConnectionKind{
+	ConnectionDialup,
+	ConnectionCableDSL,
+	ConnectionCorporate,
+	ConnectionCellular,
+	ConnectionSatellite,
+}
+
+// ParseConnectionKind returns the ConnectionKind named by s, ignoring case.
+// It returns ConnectionUnknown if s is not a known connection type.
+func ParseConnectionKind(s string) ConnectionKind {
+	return parseEnum(connectionKinds, s)
+}
+
+// IsKnown reports whether c is one of the connection types defined by this
+// package, other than ConnectionUnknown.
+func (c ConnectionKind) IsKnown() bool {
+	return c != ConnectionUnknown && ParseConnectionKind(string(c)) == c
+}
+
+// IsMobile reports whether c is a cellular connection.
+func (c ConnectionKind) IsMobile() bool {
+	return c == ConnectionCellular
+}
+
+// IsResidential reports whether c is a type of connection typically used by
+// households, i.e., dial-up or broadband.
+func (c ConnectionKind) IsResidential() bool {
+	return c == ConnectionDialup || c == ConnectionCableDSL
+}
+
+// UserType is the type of user associated with a network, as found in the
+// GeoIP2 Enterprise database. As with ConnectionKind, convert the UserType
+// field of a record to use it, e.g., UserType(record.Traits.UserType), and
+// use IsKnown or ParseUserType to handle values unknown to this package.
+type UserType string
+
+// The user types used in the databases. UserTypeUnknown, the zero value, is
+// used for networks without a user type.
+const (
+	UserTypeUnknown                UserType = ""
+	UserTypeBusiness               UserType = "business"
+	UserTypeCafe                   UserType = "cafe"
+	UserTypeCellular               UserType = "cellular"
+	UserTypeCollege                UserType = "college"
+	UserTypeConsumerPrivacyNetwork UserType = "consumer_privacy_network"
+	UserTypeContentDeliveryNetwork UserType = "content_delivery_network"
+	UserTypeDialup                 UserType = "dialup"
+	UserTypeGovernment             UserType = "government"
+	UserTypeHosting                UserType = "hosting"
+	UserTypeLibrary                UserType = "library"
+	UserTypeMilitary               UserType = "military"
+	UserTypeResidential            UserType = "residential"
+	UserTypeRouter                 UserType = "router"
+	UserTypeSchool                 UserType = "school"
+	UserTypeSearchEngineSpider     UserType = "search_engine_spider"
+	UserTypeTraveler               UserType = "traveler"
+)
+
+var userTypes = []UserType{
+	UserTypeBusiness,
+	UserTypeCafe,
+	UserTypeCellular,
+	UserTypeCollege,
+	UserTypeConsumerPrivacyNetwork,
+	UserTypeContentDeliveryNetwork,
+	UserTypeDialup,
+	UserTypeGovernment,
+	UserTypeHosting,
+	UserTypeLibrary,
+	UserTypeMilitary,
+	UserTypeResidential,
+	UserTypeRouter,
+	UserTypeSchool,
+	UserTypeSearchEngineSpider,
+	UserTypeTraveler,
+}
+
+// ParseUserType returns the UserType named by s, ignoring case. It returns
+// UserTypeUnknown if s is not a known user type.
+func ParseUserType(s string) UserType {
+	return parseEnum(userTypes, s)
+}
+
+// IsKnown reports whether u is one of the user types defined by this
+// package, other than UserTypeUnknown.
+func (u UserType) IsKnown() bool {
+	return u != UserTypeUnknown && ParseUserType(string(u)) == u
+}
+
+// IsMobile reports whether u is a cellular network.
+func (u UserType) IsMobile() bool {
+	return u == UserTypeCellular
+}
+
+// IsResidential reports whether u is a residential network, including
+// dial-up.
+func (u UserType) IsResidential() bool {
+	return u == UserTypeResidential || u == UserTypeDialup
+}
+
+// IsHosting reports whether u is a network of a hosting provider or a
+// content delivery network, i.e., one used by servers rather than people.
+func (u UserType) IsHosting() bool {
+	return u == UserTypeHosting || u == UserTypeContentDeliveryNetwork
+}
+
+// RepresentedCountryType is the type of entity represented by the
+// RepresentedCountry of a record, e.g.,
+// RepresentedCountryType(record.RepresentedCountry.Type).
+type RepresentedCountryType string
+
+// The represented country types used in the databases.
+// RepresentedCountryUnknown, the zero value, is used for records without a
+// represented country.
+const (
+	RepresentedCountryUnknown  RepresentedCountryType = ""
+	RepresentedCountryMilitary RepresentedCountryType = "military"
+)
+
+var representedCountryTypes = []RepresentedCountryType{
+	RepresentedCountryMilitary,
+}
+
+// ParseRepresentedCountryType returns the RepresentedCountryType named by s,
+// ignoring case. It returns RepresentedCountryUnknown if s is not a known
+// represented country type.
+func ParseRepresentedCountryType(s string) RepresentedCountryType {
+	return parseEnum(representedCountryTypes, s)
+}
+
+// IsKnown reports whether t is one of the represented country types defined
+// by this package, other than RepresentedCountryUnknown.
+func (t RepresentedCountryType) IsKnown() bool {
+	return t != RepresentedCountryUnknown && ParseRepresentedCountryType(string(t)) == t
+}
+
+// IsMilitary reports whether t is a military base.
+func (t RepresentedCountryType) IsMilitary() bool {
+	return t == RepresentedCountryMilitary
+}
+
+func parseEnum[T ~string](values []T, s string) T {
+	for _, v := range values {
+		if strings.EqualFold(string(v), s) {
+			return v
+		}
+	}
+	var unknown T
+	return unknown
+}
@@ -0,0 +1,73 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnectionKind(t *testing.T) {
+	assert.Equal(t, ConnectionCableDSL, ParseConnectionKind("Cable/DSL"))
+	assert.Equal(t, ConnectionCellular, ParseConnectionKind("cellular"))
+	assert.Equal(t, ConnectionUnknown, ParseConnectionKind("Fiber"))
+	assert.Equal(t, ConnectionUnknown, ParseConnectionKind(""))
+
+	assert.True(t, ConnectionSatellite.IsKnown())
+	assert.False(t, ConnectionUnknown.IsKnown())
+	assert.False(t, ConnectionKind("Fiber").IsKnown())
+	assert.False(t, ConnectionKind("cellular").IsKnown())
+
+	assert.True(t, ConnectionCellular.IsMobile())
+	assert.False(t, ConnectionCorporate.IsMobile())
+	assert.True(t, ConnectionDialup.IsResidential())
+	assert.True(t, ConnectionCableDSL.IsResidential())
+	assert.False(t, ConnectionCorporate.IsResidential())
+}
+
+func TestParseUserType(t *testing.T) {
+	assert.Equal(t, UserTypeSearchEngineSpider, ParseUserType("search_engine_spider"))
+	assert.Equal(t, UserTypeHosting, ParseUserType("HOSTING"))
+	assert.Equal(t, UserTypeUnknown, ParseUserType("spaceship"))
+
+	for _, u := range userTypes {
+		assert.True(t, u.IsKnown(), u)
+	}
+	assert.False(t, UserTypeUnknown.IsKnown())
+
+	assert.True(t, UserTypeCellular.IsMobile())
+	assert.True(t, UserTypeResidential.IsResidential())
+	assert.True(t, UserTypeDialup.IsResidential())
+	assert.False(t, UserTypeBusiness.IsResidential())
+	assert.True(t, UserTypeHosting.IsHosting())
+	assert.True(t, UserTypeContentDeliveryNetwork.IsHosting())
+	assert.False(t, UserTypeConsumerPrivacyNetwork.IsHosting())
+}
+
+func TestParseRepresentedCountryType(t *testing.T) {
+	assert.Equal(t, RepresentedCountryMilitary, ParseRepresentedCountryType("military"))
+	assert.Equal(t, RepresentedCountryUnknown, ParseRepresentedCountryType("embassy"))
+	assert.True(t, RepresentedCountryMilitary.IsMilitary())
+	assert.True(t, RepresentedCountryMilitary.IsKnown())
+	assert.False(t, RepresentedCountryUnknown.IsMilitary())
+}
+
+func TestEnumsDecoding(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.City(net.ParseIP("202.196.224.0"))
+	require.NoError(t, err)
+	assert.True(t, RepresentedCountryType(record.RepresentedCountry.Type).IsMilitary())
+
+	reader, err = Open("test-data/test-data/GeoIP2-Enterprise-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	enterprise, err := reader.Enterprise(net.ParseIP("74.209.24.0"))
+	require.NoError(t, err)
+	assert.True(t, ConnectionKind(enterprise.Traits.ConnectionType).IsResidential())
+	assert.True(t, UserType(enterprise.Traits.UserType).IsKnown())
+}
@@ -0,0 +1,511 @@
+package geoip2
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CSVWriters holds the destinations used by ExportCSV. Nil writers are
+// skipped.
+type CSVWriters struct {
+	// BlocksIPv4 receives the IPv4 networks.
+	BlocksIPv4 io.Writer
+	// BlocksIPv6 receives the IPv6 networks.
+	BlocksIPv6 io.Writer
+	// Locations maps locales, such as "en" or "pt-BR", to the writer that
+	// receives the locations with names in that locale. It is not used for
+	// ASN databases.
+	Locations map[string]io.Writer
+}
+
+type csvLayout int
+
+const (
+	csvCity csvLayout = iota
+	csvCountry
+	csvEnterprise
+	csvASN
+)
+
+func csvLayoutFor(r *Reader) (csvLayout, error) {
+	switch {
+	case r.databaseType&isEnterprise != 0:
+		return csvEnterprise, nil
+	case r.databaseType&isCity != 0:
+		if strings.Contains(r.Metadata().DatabaseType, "Country") {
+			return csvCountry, nil
+		}
+		return csvCity, nil
+	case r.databaseType&isASN != 0:
+		return csvASN, nil
+	default:
+		return 0, fmt.Errorf(
+			"geoip2: cannot export a %s database to CSV",
+			r.Metadata().DatabaseType,
+		)
+	}
+}
+
+var (
+	csvCountryBlocksHeader = []string{
+		"network",
+		"geoname_id",
+		"registered_country_geoname_id",
+		"represented_country_geoname_id",
+		"is_anonymous_proxy",
+		"is_satellite_provider",
+	}
+	csvCityBlocksHeader = append(slices.Clone(csvCountryBlocksHeader),
+		"postal_code",
+		"latitude",
+		"longitude",
+		"accuracy_radius",
+		"is_anycast",
+	)
+	csvEnterpriseBlocksHeader = append(slices.Clone(csvCityBlocksHeader),
+		"country_confidence",
+		"subdivision_1_confidence",
+		"subdivision_2_confidence",
+		"city_confidence",
+		"postal_confidence",
+		"isp",
+		"organization",
+		"autonomous_system_number",
+		"autonomous_system_organization",
+		"connection_type",
+		"user_type",
+		"domain",
+		"static_ip_score",
+		"mobile_country_code",
+		"mobile_network_code",
+		"is_legitimate_proxy",
+	)
+	csvASNBlocksHeader = []string{
+		"network",
+		"autonomous_system_number",
+		"autonomous_system_organization",
+	}
+	csvCountryLocationsHeader = []string{
+		"geoname_id",
+		"locale_code",
+		"continent_code",
+		"continent_name",
+		"country_iso_code",
+		"country_name",
+		"is_in_european_union",
+	}
+	csvCityLocationsHeader = []string{
+		"geoname_id",
+		"locale_code",
+		"continent_code",
+		"continent_name",
+		"country_iso_code",
+		"country_name",
+		"subdivision_1_iso_code",
+		"subdivision_1_name",
+		"subdivision_2_iso_code",
+		"subdivision_2_name",
+		"city_name",
+		"metro_code",
+		"time_zone",
+		"is_in_european_union",
+	}
+)
+
+// csvLocation is a row of a Locations file before it is localized.
+type csvLocation struct {
+	continentNames    map[string]string
+	countryNames      map[string]string
+	subdivision1Names map[string]string
+	subdivision2Names map[string]string
+	cityNames         map[string]string
+	continentCode     string
+	countryIsoCode    string
+	subdivision1Code  string
+	subdivision2Code  string
+	timeZone          string
+	metroCode         uint
+	isInEU            bool
+	// rank determines which row is kept when several records describe the
+	// same location. See the csvRank constants.
+	rank int
+}
+
+// ExportCSV writes the networks of a City, Country, Enterprise or ASN
+// database in the layout of MaxMind's CSV databases, e.g., the
+// GeoLite2-City-Blocks-IPv4.csv, GeoLite2-City-Blocks-IPv6.csv and
+// GeoLite2-City-Locations-en.csv files.
+//
+// Each location is written once per Locations file, keyed by its
+// GeoNameID. A block's geoname_id refers to its most specific location:
+// its city, its most specific subdivision or its country. Values missing
+// from a record are written as empty fields.
+func ExportCSV(r *Reader, writers CSVWriters) error {
+	layout, err := csvLayoutFor(r)
+	if err != nil {
+		return err
+	}
+
+	header := csvCityBlocksHeader
+	switch layout {
+	case csvCountry:
+		header = csvCountryBlocksHeader
+	case csvEnterprise:
+		header = csvEnterpriseBlocksHeader
+	case csvASN:
+		header = csvASNBlocksHeader
+	}
+
+	v4 := newCSVWriter(writers.BlocksIPv4)
+	v6 := newCSVWriter(writers.BlocksIPv6)
+	for _, w := range []*csv.Writer{v4, v6} {
+		if err := writeCSVRow(w, header); err != nil {
+			return err
+		}
+	}
+
+	locations := map[uint]*csvLocation{}
+	networks := r.Networks()
+	for networks.Next() {
+		var (
+			network *net.IPNet
+			row     []string
+		)
+		if layout == csvASN {
+			var record ASN
+			network, err = networks.Network(&record)
+			if err != nil {
+				return err
+			}
+			row = []string{
+				network.String(),
+				formatCSVUint(record.AutonomousSystemNumber),
+				record.AutonomousSystemOrganization,
+			}
+		} else {
+			var record Enterprise
+			network, err = networks.Network(&record)
+			if err != nil {
+				return err
+			}
+			row = csvBlockRow(layout, network, &record)
+			addCSVLocations(layout, locations, &record)
+		}
+
+		w := v6
+		if network.IP.To4() != nil {
+			w = v4
+		}
+		if err := writeCSVRow(w, row); err != nil {
+			return err
+		}
+	}
+	if err := networks.Err(); err != nil {
+		return err
+	}
+	for _, w := range []*csv.Writer{v4, v6} {
+		if err := flushCSV(w); err != nil {
+			return err
+		}
+	}
+
+	if layout == csvASN {
+		return nil
+	}
+	ids := make([]uint, 0, len(locations))
+	for id := range locations {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for locale, out := range writers.Locations {
+		w := newCSVWriter(out)
+		locHeader := csvCityLocationsHeader
+		if layout == csvCountry {
+			locHeader = csvCountryLocationsHeader
+		}
+		if err := writeCSVRow(w, locHeader); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := writeCSVRow(w, csvLocationRow(layout, id, locale, locations[id])); err != nil {
+				return err
+			}
+		}
+		if err := flushCSV(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportCSVFiles writes the files produced by ExportCSV to dir, naming them
+// after the database type, e.g., GeoLite2-City-Blocks-IPv4.csv. A Locations
+// file is written for each of locales, or for "en" if none are given.
+func ExportCSVFiles(r *Reader, dir string, locales ...string) (err error) {
+	if _, err := csvLayoutFor(r); err != nil {
+		return err
+	}
+	if len(locales) == 0 {
+		locales = []string{"en"}
+	}
+
+	prefix := r.Metadata().DatabaseType
+	if i := strings.Index(prefix, " ("); i >= 0 {
+		prefix = prefix[:i]
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			err = errors.Join(err, f.Close())
+		}
+	}()
+	create := func(name string) (*os.File, error) {
+		f, err := os.Create(filepath.Join(dir, prefix+"-"+name+".csv"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		return f, nil
+	}
+
+	writers := CSVWriters{Locations: map[string]io.Writer{}}
+	if writers.BlocksIPv4, err = create("Blocks-IPv4"); err != nil {
+		return err
+	}
+	if writers.BlocksIPv6, err = create("Blocks-IPv6"); err != nil {
+		return err
+	}
+	if r.databaseType&isCity != 0 {
+		for _, locale := range locales {
+			if writers.Locations[locale], err = create("Locations-" + locale); err != nil {
+				return err
+			}
+		}
+	}
+	return ExportCSV(r, writers)
+}
+
+func csvBlockRow(layout csvLayout, network *net.IPNet, record *Enterprise) []string {
+	row := []string{
+		network.String(),
+		formatCSVUint(csvLocationID(layout, record)),
+		formatCSVUint(record.RegisteredCountry.GeoNameID),
+		formatCSVUint(record.RepresentedCountry.GeoNameID),
+		formatCSVBool(record.Traits.IsAnonymousProxy),
+		formatCSVBool(record.Traits.IsSatelliteProvider),
+	}
+	if layout == csvCountry {
+		return row
+	}
+
+	loc := record.Location
+	hasLocation := loc.Latitude != 0 || loc.Longitude != 0 || loc.AccuracyRadius != 0
+	row = append(row,
+		record.Postal.Code,
+		formatCSVFloat(loc.Latitude, hasLocation),
+		formatCSVFloat(loc.Longitude, hasLocation),
+		formatCSVUint(uint(loc.AccuracyRadius)),
+		formatCSVBool(record.Traits.IsAnycast),
+	)
+	if layout == csvCity {
+		return row
+	}
+
+	var sub1, sub2 uint8
+	if len(record.Subdivisions) > 0 {
+		sub1 = record.Subdivisions[0].Confidence
+	}
+	if len(record.Subdivisions) > 1 {
+		sub2 = record.Subdivisions[1].Confidence
+	}
+	t := record.Traits
+	return append(row,
+		formatCSVUint(uint(record.Country.Confidence)),
+		formatCSVUint(uint(sub1)),
+		formatCSVUint(uint(sub2)),
+		formatCSVUint(uint(record.City.Confidence)),
+		formatCSVUint(uint(record.Postal.Confidence)),
+		t.ISP,
+		t.Organization,
+		formatCSVUint(t.AutonomousSystemNumber),
+		t.AutonomousSystemOrganization,
+		t.ConnectionType,
+		t.UserType,
+		t.Domain,
+		formatCSVFloat(t.StaticIPScore, t.StaticIPScore != 0),
+		t.MobileCountryCode,
+		t.MobileNetworkCode,
+		formatCSVBool(t.IsLegitimateProxy),
+	)
+}
+
+const (
+	// csvRankOtherCountry is used for rows built from a registered or
+	// represented country, which lack the continent.
+	csvRankOtherCountry = iota
+	// csvRankCountry is used for country rows built from a record located
+	// more specifically, which lack the country's time zone.
+	csvRankCountry
+	// csvRankLocation is used for rows built from the location of a block.
+	csvRankLocation
+)
+
+// csvLocationID returns the GeoNameID of the most specific location of
+// record: its city, its most specific subdivision or its country.
+func csvLocationID(layout csvLayout, record *Enterprise) uint {
+	if layout == csvCountry {
+		return record.Country.GeoNameID
+	}
+	if record.City.GeoNameID != 0 {
+		return record.City.GeoNameID
+	}
+	for i := len(record.Subdivisions) - 1; i >= 0; i-- {
+		if record.Subdivisions[i].GeoNameID != 0 {
+			return record.Subdivisions[i].GeoNameID
+		}
+	}
+	return record.Country.GeoNameID
+}
+
+func addCSVLocations(layout csvLayout, locations map[uint]*csvLocation, record *Enterprise) {
+	country := csvLocation{
+		continentNames: record.Continent.Names,
+		continentCode:  record.Continent.Code,
+		countryNames:   record.Country.Names,
+		countryIsoCode: record.Country.IsoCode,
+		isInEU:         record.Country.IsInEuropeanUnion,
+		rank:           csvRankCountry,
+	}
+
+	addCSVLocation(locations, record.RegisteredCountry.GeoNameID, &csvLocation{
+		countryNames:   record.RegisteredCountry.Names,
+		countryIsoCode: record.RegisteredCountry.IsoCode,
+		isInEU:         record.RegisteredCountry.IsInEuropeanUnion,
+		rank:           csvRankOtherCountry,
+	})
+	addCSVLocation(locations, record.RepresentedCountry.GeoNameID, &csvLocation{
+		countryNames:   record.RepresentedCountry.Names,
+		countryIsoCode: record.RepresentedCountry.IsoCode,
+		isInEU:         record.RepresentedCountry.IsInEuropeanUnion,
+		rank:           csvRankOtherCountry,
+	})
+
+	if layout == csvCountry {
+		country.rank = csvRankLocation
+		addCSVLocation(locations, record.Country.GeoNameID, &country)
+		return
+	}
+
+	id := csvLocationID(layout, record)
+	if id != record.Country.GeoNameID {
+		addCSVLocation(locations, record.Country.GeoNameID, &country)
+	}
+
+	loc := country
+	loc.rank = csvRankLocation
+	loc.metroCode = record.Location.MetroCode
+	loc.timeZone = record.Location.TimeZone
+	if record.City.GeoNameID != 0 {
+		loc.cityNames = record.City.Names
+	}
+	for i, sub := range record.Subdivisions {
+		switch i {
+		case 0:
+			loc.subdivision1Code = sub.IsoCode
+			loc.subdivision1Names = sub.Names
+		case 1:
+			loc.subdivision2Code = sub.IsoCode
+			loc.subdivision2Names = sub.Names
+		}
+		if sub.GeoNameID == id {
+			break
+		}
+	}
+	addCSVLocation(locations, id, &loc)
+}
+
+func addCSVLocation(locations map[uint]*csvLocation, id uint, loc *csvLocation) {
+	if id == 0 {
+		return
+	}
+	if existing, ok := locations[id]; ok && existing.rank >= loc.rank {
+		return
+	}
+	locations[id] = loc
+}
+
+func csvLocationRow(layout csvLayout, id uint, locale string, loc *csvLocation) []string {
+	row := []string{
+		formatCSVUint(id),
+		locale,
+		loc.continentCode,
+		loc.continentNames[locale],
+		loc.countryIsoCode,
+		loc.countryNames[locale],
+	}
+	if layout != csvCountry {
+		row = append(row,
+			loc.subdivision1Code,
+			loc.subdivision1Names[locale],
+			loc.subdivision2Code,
+			loc.subdivision2Names[locale],
+			loc.cityNames[locale],
+			formatCSVUint(loc.metroCode),
+			loc.timeZone,
+		)
+	}
+	return append(row, formatCSVBool(loc.isInEU))
+}
+
+func newCSVWriter(w io.Writer) *csv.Writer {
+	if w == nil {
+		return nil
+	}
+	return csv.NewWriter(w)
+}
+
+func writeCSVRow(w *csv.Writer, row []string) error {
+	if w == nil {
+		return nil
+	}
+	return w.Write(row)
+}
+
+func flushCSV(w *csv.Writer) error {
+	if w == nil {
+		return nil
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatCSVUint(v uint) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func formatCSVFloat(v float64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatCSVBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
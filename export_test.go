@@ -0,0 +1,90 @@
+package geoip2
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCSVCity(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var v4, v6, en, ja bytes.Buffer
+	err = ExportCSV(reader, CSVWriters{
+		BlocksIPv4: &v4,
+		BlocksIPv6: &v6,
+		Locations:  map[string]io.Writer{"en": &en, "ja": &ja},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(
+		v4.String(),
+		"network,geoname_id,registered_country_geoname_id,represented_country_geoname_id,"+
+			"is_anonymous_proxy,is_satellite_provider,postal_code,latitude,longitude,"+
+			"accuracy_radius,is_anycast\n",
+	))
+	assert.Contains(t, v4.String(), ",2643743,6252001,,0,0,")
+	assert.Contains(t, v4.String(), ",51.5142,-0.0931,100,0\n")
+	assert.NotContains(t, v4.String(), "::")
+	assert.NotEmpty(t, v6.String())
+
+	assert.True(t, strings.HasPrefix(en.String(), strings.Join(csvCityLocationsHeader, ",")+"\n"))
+	assert.Contains(t, en.String(), "2643743,en,EU,Europe,GB,United Kingdom,ENG,England,")
+	assert.Equal(t, 1, strings.Count(en.String(), "\n2643743,"))
+	assert.Contains(t, ja.String(), "2643743,ja,EU,ヨーロッパ,GB,イギリス,ENG,,,,ロンドン,,Europe/London,0\n")
+}
+
+func TestExportCSVASN(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var v4 bytes.Buffer
+	require.NoError(t, ExportCSV(reader, CSVWriters{BlocksIPv4: &v4}))
+
+	assert.True(t, strings.HasPrefix(
+		v4.String(),
+		"network,autonomous_system_number,autonomous_system_organization\n",
+	))
+	assert.Contains(t, v4.String(), "\n1.128.0.0/11,1221,Telstra Pty Ltd\n")
+}
+
+func TestExportCSVFiles(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, ExportCSVFiles(reader, dir, "en", "de"))
+
+	for _, name := range []string{
+		"GeoIP2-Country-Blocks-IPv4.csv",
+		"GeoIP2-Country-Blocks-IPv6.csv",
+		"GeoIP2-Country-Locations-en.csv",
+		"GeoIP2-Country-Locations-de.csv",
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+	}
+
+	en, err := os.ReadFile(filepath.Join(dir, "GeoIP2-Country-Locations-en.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(en), "\n2635167,en,EU,Europe,GB,United Kingdom,0\n")
+}
+
+func TestExportCSVUnsupported(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Domain-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	err = ExportCSV(reader, CSVWriters{})
+	assert.EqualError(t, err, "geoip2: cannot export a GeoIP2-Domain database to CSV")
+}
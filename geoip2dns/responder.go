@@ -0,0 +1,308 @@
+// Package geoip2dns provides a DNS server that answers TXT queries for IP
+// addresses with data from GeoIP2 and GeoLite2 databases, in the style of
+// Team Cymru's IP to ASN mapping service.
+//
+// Queries are made for the labels of an address in reverse order, followed
+// by the kind of lookup and the zone of the Responder. For the zone
+// geo.local, the country of 192.0.2.1 is queried with
+//
+//	dig +short TXT 1.2.0.192.country.geo.local
+//
+// IPv6 addresses use the nibble format of ip6.arpa, i.e., 32 hexadecimal
+// digits in reverse order. The answers are a single string of fields
+// separated by " | ":
+//
+//	country  "COUNTRY | NETWORK | CONTINENT | REGISTERED_COUNTRY"
+//	asn      "ASN | NETWORK | ORGANIZATION"
+//
+// For example, "SE | 89.160.20.112/28 | EU | DE" and
+// "1221 | 1.128.0.0/11 | Telstra Pty Ltd". Addresses that are not in the
+// database are answered with NXDOMAIN.
+package geoip2dns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// DefaultTTL is the TTL of answers when Config.TTL is zero.
+const DefaultTTL = 3600
+
+// maxMessageSize is the largest DNS message read from a connection.
+const maxMessageSize = 65535
+
+// maxTXTLength is the maximum length of a string in a TXT record.
+const maxTXTLength = 255
+
+// Config configures a Responder. Queries are only answered for the kinds of
+// lookup whose reader is set.
+type Config struct {
+	// Country answers country queries. It may be a Country, City or
+	// Enterprise database.
+	Country *geoip2.Reader
+	// ASN answers asn queries. It may be an ASN or an ISP database.
+	ASN *geoip2.Reader
+	// OnError, if set, is called with the errors of failed lookups and of
+	// messages that cannot be parsed.
+	OnError func(err error)
+	// Zone is the domain under which queries are answered, e.g.,
+	// "geo.local". Queries for other names are refused.
+	Zone string
+	// TTL is the TTL of answers in seconds. If zero, DefaultTTL is used.
+	TTL uint32
+}
+
+// Responder answers DNS queries for the country and autonomous system of IP
+// addresses. A Responder is safe for concurrent use.
+type Responder struct {
+	config Config
+	// suffix is the zone in lowercase with leading and trailing dots.
+	suffix string
+}
+
+// NewResponder returns a Responder using config. It returns an error if
+// the zone is empty or a reader does not support its kind of lookup.
+func NewResponder(config Config) (*Responder, error) {
+	zone := strings.Trim(strings.ToLower(config.Zone), ".")
+	if zone == "" {
+		return nil, errors.New("geoip2dns: a zone is required")
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultTTL
+	}
+	if err := checkReader(config.Country, "Country"); err != nil {
+		return nil, err
+	}
+	if err := checkReader(config.ASN, "ASN"); err != nil {
+		return nil, err
+	}
+	return &Responder{config: config, suffix: "." + zone + "."}, nil
+}
+
+// checkReader returns a geoip2.InvalidMethodError if reader is set but does
+// not support the lookup method.
+func checkReader(reader *geoip2.Reader, method string) error {
+	if reader == nil || reader.Supports(method) {
+		return nil
+	}
+	return geoip2.InvalidMethodError{Method: method, DatabaseType: reader.Metadata().DatabaseType}
+}
+
+// Serve answers the queries received on conn, e.g., a UDP connection
+// returned by net.ListenPacket, until reading from it fails. It returns
+// the error that stopped it, which is net.ErrClosed if conn was closed.
+// Messages that are not valid queries are dropped.
+func (r *Responder) Serve(conn net.PacketConn) error {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		response, err := r.Respond(buf[:n])
+		if err != nil {
+			r.reportError(err)
+			continue
+		}
+		if _, err := conn.WriteTo(response, addr); err != nil {
+			r.reportError(err)
+		}
+	}
+}
+
+// Respond returns the response to the DNS query message query. It returns
+// an error if query is not a valid query, in which case no response should
+// be sent.
+func (r *Responder) Respond(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, fmt.Errorf("geoip2dns: parsing query: %w", err)
+	}
+	if header.Response {
+		return nil, errors.New("geoip2dns: message is a response")
+	}
+	question, err := p.Question()
+	if errors.Is(err, dnsmessage.ErrSectionDone) {
+		return r.build(header, nil, "", dnsmessage.RCodeFormatError)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("geoip2dns: parsing question: %w", err)
+	}
+	if header.OpCode != 0 {
+		return r.build(header, &question, "", dnsmessage.RCodeNotImplemented)
+	}
+
+	text, rcode := r.answer(question)
+	return r.build(header, &question, text, rcode)
+}
+
+// answer returns the TXT string for question, which is empty if there is
+// no answer, and the response code.
+func (r *Responder) answer(question dnsmessage.Question) (string, dnsmessage.RCode) {
+	name := strings.ToLower(question.Name.String())
+	if question.Class != dnsmessage.ClassINET {
+		return "", dnsmessage.RCodeRefused
+	}
+	if name == r.suffix[1:] {
+		// The zone apex exists but has no records.
+		return "", dnsmessage.RCodeSuccess
+	}
+	if !strings.HasSuffix(name, r.suffix) {
+		return "", dnsmessage.RCodeRefused
+	}
+	labels := strings.Split(strings.TrimSuffix(name, r.suffix), ".")
+	kind := labels[len(labels)-1]
+	ip, ok := parseReversed(labels[:len(labels)-1])
+	if !ok {
+		return "", dnsmessage.RCodeNameError
+	}
+
+	var text string
+	var found bool
+	var err error
+	switch {
+	case kind == "country" && r.config.Country != nil:
+		text, found, err = r.country(ip)
+	case kind == "asn" && r.config.ASN != nil:
+		text, found, err = r.asn(ip)
+	default:
+		return "", dnsmessage.RCodeNameError
+	}
+	switch {
+	case err != nil:
+		r.reportError(err)
+		return "", dnsmessage.RCodeServerFailure
+	case !found:
+		return "", dnsmessage.RCodeNameError
+	case question.Type != dnsmessage.TypeTXT && question.Type != dnsmessage.TypeALL:
+		// The name exists but has no records of the requested type.
+		return "", dnsmessage.RCodeSuccess
+	}
+	return text, dnsmessage.RCodeSuccess
+}
+
+func (r *Responder) country(ip net.IP) (string, bool, error) {
+	var record geoip2.Country
+	network, found, err := r.config.Country.LookupNetwork(ip, &record)
+	if err != nil || !found || record.Country.IsoCode == "" && record.RegisteredCountry.IsoCode == "" {
+		return "", false, err
+	}
+	return fmt.Sprintf("%s | %s | %s | %s",
+		record.Country.IsoCode,
+		network,
+		record.Continent.Code,
+		record.RegisteredCountry.IsoCode,
+	), true, nil
+}
+
+func (r *Responder) asn(ip net.IP) (string, bool, error) {
+	var record geoip2.ASN
+	network, found, err := r.config.ASN.LookupNetwork(ip, &record)
+	if err != nil || !found || record.AutonomousSystemNumber == 0 {
+		return "", false, err
+	}
+	return fmt.Sprintf("%d | %s | %s",
+		record.AutonomousSystemNumber,
+		network,
+		record.AutonomousSystemOrganization,
+	), true, nil
+}
+
+// build returns a response to the query with header and question, which
+// has text as its only TXT answer if text is not empty.
+func (r *Responder) build(
+	header dnsmessage.Header,
+	question *dnsmessage.Question,
+	text string,
+	rcode dnsmessage.RCode,
+) ([]byte, error) {
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{
+		ID:               header.ID,
+		Response:         true,
+		OpCode:           header.OpCode,
+		Authoritative:    rcode == dnsmessage.RCodeSuccess || rcode == dnsmessage.RCodeNameError,
+		RecursionDesired: header.RecursionDesired,
+		RCode:            rcode,
+	})
+	b.EnableCompression()
+	if question == nil {
+		return b.Finish()
+	}
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(*question); err != nil {
+		return nil, err
+	}
+	if text != "" {
+		if err := b.StartAnswers(); err != nil {
+			return nil, err
+		}
+		err := b.TXTResource(
+			dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: r.config.TTL},
+			dnsmessage.TXTResource{TXT: splitTXT(text)},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// splitTXT splits text into the strings of a TXT record, which are limited
+// to 255 bytes each.
+func splitTXT(text string) []string {
+	var txt []string
+	for len(text) > maxTXTLength {
+		txt = append(txt, text[:maxTXTLength])
+		text = text[maxTXTLength:]
+	}
+	return append(txt, text)
+}
+
+func (r *Responder) reportError(err error) {
+	if r.config.OnError != nil {
+		r.config.OnError(err)
+	}
+}
+
+// parseReversed parses the labels of an IP address in reverse order: the
+// four decimal octets of an IPv4 address or the 32 hexadecimal nibbles of an
+// IPv6 address.
+func parseReversed(labels []string) (net.IP, bool) {
+	switch len(labels) {
+	case net.IPv4len:
+		octets := make([]string, net.IPv4len)
+		for i, label := range labels {
+			octets[net.IPv4len-1-i] = label
+		}
+		addr, err := netip.ParseAddr(strings.Join(octets, "."))
+		if err != nil || !addr.Is4() {
+			return nil, false
+		}
+		return net.IP(addr.AsSlice()), true
+	case 2 * net.IPv6len:
+		ip := make(net.IP, net.IPv6len)
+		for i, label := range labels {
+			nibble, err := strconv.ParseUint(label, 16, 4)
+			if err != nil || len(label) != 1 {
+				return nil, false
+			}
+			// labels[0] is the low nibble of the last byte.
+			j := len(labels) - 1 - i
+			ip[j/2] |= byte(nibble) << (4 * (1 - j%2))
+		}
+		return ip, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,261 @@
+package geoip2dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+const testDataDir = "../test-data/test-data/"
+
+func openReader(t *testing.T, name string) *geoip2.Reader {
+	t.Helper()
+
+	reader, err := geoip2.Open(testDataDir + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
+
+// startResponder serves a Responder on a local UDP listener and returns a
+// connection to it.
+func startResponder(t *testing.T, config Config) net.Conn {
+	t.Helper()
+
+	responder, err := NewResponder(config)
+	require.NoError(t, err)
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- responder.Serve(listener) }()
+	t.Cleanup(func() {
+		listener.Close()
+		assert.ErrorIs(t, <-done, net.ErrClosed)
+	})
+
+	conn, err := net.Dial("udp", listener.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func buildQuery(t *testing.T, id uint16, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	require.NoError(t, b.StartQuestions())
+	require.NoError(t, b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  qtype,
+		Class: dnsmessage.ClassINET,
+	}))
+	query, err := b.Finish()
+	require.NoError(t, err)
+	return query
+}
+
+func exchange(t *testing.T, conn net.Conn, name string, qtype dnsmessage.Type) dnsmessage.Message {
+	t.Helper()
+
+	_, err := conn.Write(buildQuery(t, 0xbeef, name, qtype))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, maxMessageSize)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+
+	var response dnsmessage.Message
+	require.NoError(t, response.Unpack(buf[:n]))
+	assert.Equal(t, uint16(0xbeef), response.Header.ID)
+	assert.True(t, response.Header.Response)
+	assert.True(t, response.Header.RecursionDesired)
+	require.Len(t, response.Questions, 1)
+	assert.Equal(t, name, response.Questions[0].Name.String())
+	return response
+}
+
+func txt(t *testing.T, response dnsmessage.Message) []string {
+	t.Helper()
+
+	var answers []string
+	for _, answer := range response.Answers {
+		assert.Equal(t, uint32(DefaultTTL), answer.Header.TTL)
+		record, ok := answer.Body.(*dnsmessage.TXTResource)
+		require.True(t, ok)
+		answers = append(answers, strings.Join(record.TXT, ""))
+	}
+	return answers
+}
+
+func TestResponder(t *testing.T) {
+	conn := startResponder(t, Config{
+		Country: openReader(t, "GeoIP2-Country-Test.mmdb"),
+		ASN:     openReader(t, "GeoLite2-ASN-Test.mmdb"),
+		Zone:    "geo.local",
+	})
+
+	tests := []struct {
+		name   string
+		txt    []string
+		qtype  dnsmessage.Type
+		rcode  dnsmessage.RCode
+		noAuth bool
+	}{
+		{
+			name:  "160.69.2.81.country.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			txt:   []string{"GB | 81.2.69.128/26 | EU | US"},
+		},
+		{
+			name:  "0.0.128.1.asn.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			txt:   []string{"1221 | 1.128.0.0/11 | Telstra Pty Ltd"},
+		},
+		{
+			name:  "112.20.160.89.Country.GEO.Local.",
+			qtype: dnsmessage.TypeALL,
+			txt:   []string{"SE | 89.160.20.112/28 | EU | DE"},
+		},
+		{
+			// 2001:218::
+			name:  strings.Repeat("0.", 24) + "8.1.2.0.1.0.0.2.country.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			txt:   []string{"JP | 2001:218::/32 | AS | JP"},
+		},
+		{
+			name:  "160.69.2.81.country.geo.local.",
+			qtype: dnsmessage.TypeA,
+		},
+		{
+			name:  "1.1.1.1.country.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			rcode: dnsmessage.RCodeNameError,
+		},
+		{
+			name:  "160.69.2.81.city.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			rcode: dnsmessage.RCodeNameError,
+		},
+		{
+			name:  "69.2.81.country.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			rcode: dnsmessage.RCodeNameError,
+		},
+		{
+			name:  "256.69.2.81.country.geo.local.",
+			qtype: dnsmessage.TypeTXT,
+			rcode: dnsmessage.RCodeNameError,
+		},
+		{
+			name:  "Geo.Local.",
+			qtype: dnsmessage.TypeTXT,
+		},
+		{
+			name:   "local.",
+			qtype:  dnsmessage.TypeTXT,
+			rcode:  dnsmessage.RCodeRefused,
+			noAuth: true,
+		},
+		{
+			name:   "160.69.2.81.country.example.com.",
+			qtype:  dnsmessage.TypeTXT,
+			rcode:  dnsmessage.RCodeRefused,
+			noAuth: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name+" "+test.qtype.String(), func(t *testing.T) {
+			response := exchange(t, conn, test.name, test.qtype)
+			assert.Equal(t, test.rcode, response.Header.RCode)
+			assert.Equal(t, !test.noAuth, response.Header.Authoritative)
+			assert.Equal(t, test.txt, txt(t, response))
+		})
+	}
+}
+
+func TestResponderTTL(t *testing.T) {
+	responder, err := NewResponder(Config{
+		Country: openReader(t, "GeoIP2-Country-Test.mmdb"),
+		Zone:    ".geo.local.",
+		TTL:     60,
+	})
+	require.NoError(t, err)
+
+	message, err := responder.Respond(buildQuery(t, 1, "160.69.2.81.country.geo.local.", dnsmessage.TypeTXT))
+	require.NoError(t, err)
+
+	var response dnsmessage.Message
+	require.NoError(t, response.Unpack(message))
+	require.Len(t, response.Answers, 1)
+	assert.Equal(t, uint32(60), response.Answers[0].Header.TTL)
+}
+
+func TestRespondInvalid(t *testing.T) {
+	responder, err := NewResponder(Config{Zone: "geo.local"})
+	require.NoError(t, err)
+
+	_, err = responder.Respond([]byte{1, 2, 3})
+	require.Error(t, err)
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 1, Response: true})
+	message, err := b.Finish()
+	require.NoError(t, err)
+	_, err = responder.Respond(message)
+	require.Error(t, err)
+
+	b = dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 1})
+	message, err = b.Finish()
+	require.NoError(t, err)
+	message, err = responder.Respond(message)
+	require.NoError(t, err)
+	var response dnsmessage.Message
+	require.NoError(t, response.Unpack(message))
+	assert.Equal(t, dnsmessage.RCodeFormatError, response.Header.RCode)
+}
+
+func TestNewResponderErrors(t *testing.T) {
+	_, err := NewResponder(Config{})
+	require.Error(t, err)
+
+	_, err = NewResponder(Config{
+		Zone: "geo.local",
+		ASN:  openReader(t, "GeoIP2-Country-Test.mmdb"),
+	})
+	var invalid geoip2.InvalidMethodError
+	require.True(t, errors.As(err, &invalid), err)
+	assert.Equal(t, "ASN", invalid.Method)
+}
+
+type countingObserver struct{ lookups int }
+
+func (o *countingObserver) ObserveLookup(context.Context, geoip2.LookupEvent) {
+	o.lookups++
+}
+
+func TestNewResponderDoesNotLookUp(t *testing.T) {
+	reader := openReader(t, "GeoIP2-Country-Test.mmdb")
+	observer := &countingObserver{}
+	reader.SetObserver(observer)
+
+	_, err := NewResponder(Config{Zone: "geo.local", Country: reader})
+	require.NoError(t, err)
+	assert.Zero(t, observer.lookups)
+}
+
+func TestSplitTXT(t *testing.T) {
+	assert.Equal(t, []string{"short"}, splitTXT("short"))
+
+	long := strings.Repeat("a", 300)
+	assert.Equal(t, []string{long[:255], long[255:]}, splitTXT(long))
+}
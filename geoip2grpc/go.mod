@@ -0,0 +1,27 @@
+module github.com/oschwald/geoip2-golang/geoip2grpc
+
+go 1.23.0
+
+require (
+	github.com/oschwald/geoip2-golang v1.13.0
+	github.com/stretchr/testify v1.10.0
+	google.golang.org/grpc v1.73.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/oschwald/maxminddb-golang v1.13.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// Use the root module from this repository until it is released with the
+// APIs used by this module (geoip2http and the Context lookup methods).
+// TODO: once the root module is tagged, require that version above and
+// drop this replace, which Go ignores when this module is a dependency.
+replace github.com/oschwald/geoip2-golang => ../
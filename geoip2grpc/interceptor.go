@@ -0,0 +1,143 @@
+// Package geoip2grpc provides gRPC server interceptors that geolocate the
+// clients of calls using GeoIP2 and GeoLite2 databases.
+//
+// Results are stored in the context of calls as a geoip2http.Result, so the
+// accessor functions of the geoip2http package, such as geoip2http.City,
+// work for both HTTP and gRPC handlers.
+package geoip2grpc
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/oschwald/geoip2-golang/geoip2http"
+)
+
+// Config configures an Interceptor.
+type Config struct {
+	geoip2http.Readers
+	// OnError, if set, is called with the errors of failed lookups. The
+	// call is handled regardless, without the failed result.
+	OnError func(ctx context.Context, err error)
+	// ForwardedForKey is the metadata key, e.g., "x-forwarded-for", that
+	// proxies use to pass on the address of the client. It is only read
+	// if the peer is in TrustedProxies. See ClientAddr.
+	ForwardedForKey string
+	// TrustedProxies are the networks of the proxies whose
+	// ForwardedForKey metadata is trusted.
+	TrustedProxies []netip.Prefix
+}
+
+// Interceptor geolocates the clients of calls and stores the results in the
+// context of the calls.
+type Interceptor struct {
+	config Config
+}
+
+// NewInterceptor returns an Interceptor using config.
+func NewInterceptor(config Config) *Interceptor {
+	return &Interceptor{config: config}
+}
+
+// Unary returns a unary server interceptor, to be passed to
+// grpc.UnaryInterceptor or grpc.ChainUnaryInterceptor.
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		return handler(i.enrich(ctx), req)
+	}
+}
+
+// Stream returns a stream server interceptor, to be passed to
+// grpc.StreamInterceptor or grpc.ChainStreamInterceptor.
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &serverStream{ServerStream: stream, ctx: i.enrich(stream.Context())})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (i *Interceptor) enrich(ctx context.Context) context.Context {
+	if result := i.Lookup(ctx); result != nil {
+		return geoip2http.NewContext(ctx, result)
+	}
+	return ctx
+}
+
+// Lookup geolocates the client of the call with context ctx. It returns nil
+// if the client address cannot be determined. ctx is passed to the observers
+// of the readers, see geoip2http.Readers.Lookup.
+func (i *Interceptor) Lookup(ctx context.Context) *geoip2http.Result {
+	addr, ok := ClientAddr(ctx, i.config.ForwardedForKey, i.config.TrustedProxies)
+	if !ok {
+		return nil
+	}
+	var onError func(error)
+	if i.config.OnError != nil {
+		onError = func(err error) { i.config.OnError(ctx, err) }
+	}
+	return i.config.Readers.Lookup(ctx, addr, onError)
+}
+
+// ClientAddr returns the address of the client of the call with context
+// ctx.
+//
+// If the address of the peer is in one of trustedProxies and
+// forwardedForKey is not empty, the comma-separated addresses in the
+// forwardedForKey metadata are walked from the right, and the first address
+// that is not in trustedProxies is returned, as with the X-Forwarded-For
+// header in geoip2http.ClientAddr. See geoip2http.ResolveClientAddr.
+func ClientAddr(ctx context.Context, forwardedForKey string, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}, false
+	}
+	addr, ok := peerAddr(p.Addr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if forwardedForKey == "" {
+		return addr, true
+	}
+
+	var hops []string
+	for _, value := range metadata.ValueFromIncomingContext(ctx, forwardedForKey) {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	return geoip2http.ResolveClientAddr(addr, hops, trustedProxies), true
+}
+
+func peerAddr(addr net.Addr) (netip.Addr, bool) {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		a, ok := netip.AddrFromSlice(tcp.IP)
+		return a.Unmap(), ok
+	}
+	addrPort, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addrPort.Addr().Unmap(), true
+}
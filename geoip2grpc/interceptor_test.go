@@ -0,0 +1,188 @@
+package geoip2grpc
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/oschwald/geoip2-golang/geoip2http"
+)
+
+const testDataDir = "../test-data/test-data/"
+
+// peerListener is a bufconn listener whose connections appear to come from
+// remoteAddr.
+type peerListener struct {
+	*bufconn.Listener
+	remoteAddr net.Addr
+}
+
+func (l *peerListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &peerConn{Conn: conn, remoteAddr: l.remoteAddr}, nil
+}
+
+type peerConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *peerConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// startServer starts a health server using the interceptors of i, reached
+// from remoteAddr, and returns a client for it along with a function
+// returning the last Result seen by the server.
+func startServer(
+	t *testing.T,
+	i *Interceptor,
+	remoteAddr string,
+) (healthpb.HealthClient, func() *geoip2http.Result) {
+	t.Helper()
+
+	var last *geoip2http.Result
+	capture := func(ctx context.Context) {
+		last = geoip2http.FromContext(ctx)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			i.Unary(),
+			func(
+				ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+			) (any, error) {
+				capture(ctx)
+				return handler(ctx, req)
+			},
+		),
+		grpc.ChainStreamInterceptor(
+			i.Stream(),
+			func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				capture(ss.Context())
+				return handler(srv, ss)
+			},
+		),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	listener := &peerListener{
+		Listener:   bufconn.Listen(1 << 20),
+		remoteAddr: net.TCPAddrFromAddrPort(netip.MustParseAddrPort(remoteAddr)),
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn), func() *geoip2http.Result { return last }
+}
+
+func openReader(t *testing.T, name string) *geoip2.Reader {
+	t.Helper()
+	reader, err := geoip2.Open(testDataDir + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	i := NewInterceptor(Config{Readers: geoip2http.Readers{
+		City: openReader(t, "GeoIP2-City-Test.mmdb"),
+		ASN:  openReader(t, "GeoLite2-ASN-Test.mmdb"),
+	}})
+	client, last := startServer(t, i, "81.2.69.160:4711")
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	result := last()
+	require.NotNil(t, result)
+	assert.Equal(t, "81.2.69.160", result.IP.String())
+	require.NotNil(t, result.City)
+	assert.Equal(t, "London", result.City.City.Names["en"])
+	assert.NotNil(t, result.ASN)
+	assert.Nil(t, result.Country)
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	i := NewInterceptor(Config{
+		Readers: geoip2http.Readers{Country: openReader(t, "GeoIP2-Country-Test.mmdb")},
+	})
+	client, last := startServer(t, i, "[2001:218::1]:4711")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	result := last()
+	require.NotNil(t, result)
+	require.NotNil(t, result.Country)
+	assert.Equal(t, "JP", result.Country.Country.IsoCode)
+}
+
+func TestForwardedFor(t *testing.T) {
+	var errs []error
+	i := NewInterceptor(Config{
+		Readers: geoip2http.Readers{
+			// The ASN database does not support City lookups.
+			City:    openReader(t, "GeoLite2-ASN-Test.mmdb"),
+			Country: openReader(t, "GeoIP2-Country-Test.mmdb"),
+		},
+		ForwardedForKey: "x-forwarded-for",
+		TrustedProxies:  []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		OnError: func(_ context.Context, err error) {
+			errs = append(errs, err)
+		},
+	})
+	client, last := startServer(t, i, "10.0.0.1:4711")
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-forwarded-for", "192.0.2.1, 81.2.69.160, 10.1.2.3")
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	result := last()
+	require.NotNil(t, result)
+	assert.Equal(t, "81.2.69.160", result.IP.String())
+	assert.Nil(t, result.City)
+	require.NotNil(t, result.Country)
+	assert.Equal(t, "GB", result.Country.Country.IsoCode)
+	require.Len(t, errs, 1)
+	assert.IsType(t, geoip2.InvalidMethodError{}, errs[0])
+
+	// Without forwarded metadata, the proxy itself is the client.
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", last().IP.String())
+}
+
+func TestClientAddrWithoutPeer(t *testing.T) {
+	_, ok := ClientAddr(context.Background(), "", nil)
+	assert.False(t, ok)
+	assert.Nil(t, NewInterceptor(Config{}).Lookup(context.Background()))
+}
@@ -0,0 +1,237 @@
+package geoip2http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// AccessRule is a rule of an AccessControl. It matches a request if any of
+// its criteria match the client: its country is in Countries, its continent
+// is in Continents, EuropeanUnion is set and its country is in the EU, or
+// its autonomous system is in ASNs. Codes are case-insensitive.
+type AccessRule struct {
+	// Name identifies the rule in decisions, e.g., for logging.
+	Name          string
+	Countries     []geoip2.CountryCode
+	Continents    []geoip2.ContinentCode
+	ASNs          []uint
+	EuropeanUnion bool
+	Action        geoip2.PolicyAction
+}
+
+// AccessConfig configures an AccessControl.
+type AccessConfig struct {
+	// Country is used for Country lookups. It may be a Country, City or
+	// Enterprise database.
+	Country *geoip2.Reader
+	// ASN is used for ASN lookups. It is only needed for rules with ASNs.
+	ASN *geoip2.Reader
+	// Blocked serves blocked requests. If nil, they are answered with 403
+	// Forbidden. Use http.RedirectHandler to redirect them instead.
+	Blocked http.Handler
+	// OnBlock, if set, is called for every blocked request, including those
+	// served because of DryRun.
+	OnBlock func(r *http.Request, decision AccessDecision)
+	// OnError, if set, is called with the errors of failed lookups. The
+	// default action is taken for such requests.
+	OnError func(r *http.Request, err error)
+	// Rules are evaluated in order and the first one that matches decides.
+	Rules []AccessRule
+	// Exempt are networks whose clients are always allowed.
+	Exempt []netip.Prefix
+	// TrustedProxies are the networks of the proxies whose forwarding
+	// headers are trusted. See ClientAddr.
+	TrustedProxies []netip.Prefix
+	// DefaultAction is taken when no rule matches, including when the
+	// client cannot be located.
+	DefaultAction geoip2.PolicyAction
+	// DryRun serves blocked requests as if they were allowed. They are
+	// still reported to OnBlock, which is required in dry-run mode.
+	DryRun bool
+}
+
+// AccessDecision is the decision of an AccessControl for a request.
+type AccessDecision struct {
+	// Rule is a copy of the rule that matched, with its codes in upper
+	// case, or nil if the default action was taken or the client is exempt.
+	Rule *AccessRule
+	// IP is the address of the client, if it could be determined.
+	IP     net.IP
+	Action geoip2.PolicyAction
+	// Exempt reports whether the client is in an exempt network.
+	Exempt bool
+}
+
+// Blocked reports whether the decision is to block.
+func (d AccessDecision) Blocked() bool {
+	return d.Action == geoip2.PolicyBlock
+}
+
+// AccessControl allows or blocks requests based on the country, continent
+// or autonomous system of their client.
+//
+// The country of a client is the country where its IP address is located
+// or, if unknown, the country in which its network is registered. If the
+// request went through a Middleware, its Country and ASN results are used
+// instead of looking the client up again.
+type AccessControl struct {
+	config   AccessConfig
+	needsASN bool
+}
+
+// NewAccessControl returns an AccessControl using config. It returns a
+// geoip2.InvalidCodeError if a rule lists an invalid country or continent
+// code, so that a misspelled code does not silently let requests through,
+// and an error if DryRun is set without OnBlock.
+func NewAccessControl(config AccessConfig) (*AccessControl, error) {
+	if config.DryRun && config.OnBlock == nil {
+		return nil, errors.New("geoip2http: OnBlock is required in dry-run mode")
+	}
+	rules := make([]AccessRule, len(config.Rules))
+	for i, rule := range config.Rules {
+		rule.Countries = make([]geoip2.CountryCode, len(rule.Countries))
+		for j, code := range config.Rules[i].Countries {
+			c, err := geoip2.ParseCountryCode(string(code))
+			if err != nil {
+				return nil, err
+			}
+			rule.Countries[j] = c
+		}
+		rule.Continents = make([]geoip2.ContinentCode, len(rule.Continents))
+		for j, code := range config.Rules[i].Continents {
+			c, err := geoip2.ParseContinentCode(string(code))
+			if err != nil {
+				return nil, err
+			}
+			rule.Continents[j] = c
+		}
+		rule.ASNs = slices.Clone(rule.ASNs)
+		rules[i] = rule
+	}
+	config.Rules = rules
+	return &AccessControl{
+		config: config,
+		needsASN: slices.ContainsFunc(config.Rules, func(rule AccessRule) bool {
+			return len(rule.ASNs) > 0
+		}),
+	}, nil
+}
+
+// Handler returns a handler that passes the requests allowed by the access
+// control to next.
+func (a *AccessControl) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision := a.Decide(r)
+		if !decision.Blocked() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if a.config.OnBlock != nil {
+			a.config.OnBlock(r, decision)
+		}
+
+		switch {
+		case a.config.DryRun:
+			next.ServeHTTP(w, r)
+		case a.config.Blocked != nil:
+			a.config.Blocked.ServeHTTP(w, r)
+		default:
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	})
+}
+
+// Decide returns the decision for r without serving it. The decision is
+// returned even in dry-run mode.
+func (a *AccessControl) Decide(r *http.Request) AccessDecision {
+	decision := AccessDecision{Action: a.config.DefaultAction}
+
+	result := FromContext(r.Context())
+	var addr netip.Addr
+	if result != nil && result.IP != nil {
+		addr, _ = netip.AddrFromSlice(result.IP)
+	} else if client, ok := ClientAddr(r, a.config.TrustedProxies); ok {
+		addr = client
+	}
+	if !addr.IsValid() {
+		return decision
+	}
+	addr = addr.Unmap()
+	decision.IP = net.IP(addr.AsSlice())
+
+	if isTrusted(addr, a.config.Exempt) {
+		decision.Exempt = true
+		decision.Action = geoip2.PolicyAllow
+		return decision
+	}
+
+	var country *geoip2.Country
+	var asn *geoip2.ASN
+	if result != nil {
+		country, asn = result.Country, result.ASN
+	}
+	var err error
+	if country == nil && a.config.Country != nil {
+		if country, err = a.config.Country.CountryContext(r.Context(), decision.IP); err != nil {
+			a.reportError(r, err)
+			return decision
+		}
+	}
+	if asn == nil && a.needsASN && a.config.ASN != nil {
+		if asn, err = a.config.ASN.ASNContext(r.Context(), decision.IP); err != nil {
+			a.reportError(r, err)
+			return decision
+		}
+	}
+
+	for i := range a.config.Rules {
+		if a.config.Rules[i].matches(country, asn) {
+			// The decision gets a copy so that the access control cannot be
+			// changed through it.
+			rule := a.config.Rules[i]
+			rule.Countries = slices.Clone(rule.Countries)
+			rule.Continents = slices.Clone(rule.Continents)
+			rule.ASNs = slices.Clone(rule.ASNs)
+			decision.Rule = &rule
+			decision.Action = rule.Action
+			break
+		}
+	}
+	return decision
+}
+
+func (a *AccessControl) reportError(r *http.Request, err error) {
+	if a.config.OnError != nil {
+		a.config.OnError(r, err)
+	}
+}
+
+func (r *AccessRule) matches(country *geoip2.Country, asn *geoip2.ASN) bool {
+	if country != nil {
+		code := country.Country.IsoCode
+		isEU := country.Country.IsInEuropeanUnion
+		if code == "" {
+			code = country.RegisteredCountry.IsoCode
+			isEU = country.RegisteredCountry.IsInEuropeanUnion
+		}
+		if code != "" && slices.Contains(r.Countries, geoip2.CountryCode(code)) {
+			return true
+		}
+		if r.EuropeanUnion && isEU {
+			return true
+		}
+		if code := geoip2.ContinentCode(country.Continent.Code); code != "" && slices.Contains(r.Continents, code) {
+			return true
+		}
+	}
+	if asn != nil && asn.AutonomousSystemNumber != 0 {
+		return slices.Contains(r.ASNs, asn.AutonomousSystemNumber)
+	}
+	return false
+}
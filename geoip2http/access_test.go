@@ -0,0 +1,168 @@
+package geoip2http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok"))
+})
+
+func serve(handler http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/page", nil)
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestAccessControl(t *testing.T) {
+	var blocked []AccessDecision
+	a, err := NewAccessControl(AccessConfig{
+		Country: openReader(t, "GeoIP2-Country-Test.mmdb"),
+		ASN:     openReader(t, "GeoLite2-ASN-Test.mmdb"),
+		Rules: []AccessRule{
+			{Name: "block-gb", Countries: []geoip2.CountryCode{"GB"}, Action: geoip2.PolicyBlock},
+			{Name: "block-eu", EuropeanUnion: true, Action: geoip2.PolicyBlock},
+			{Name: "block-asia", Continents: []geoip2.ContinentCode{geoip2.ContinentAsia}, Action: geoip2.PolicyBlock},
+			{Name: "block-as1221", ASNs: []uint{1221}, Action: geoip2.PolicyBlock},
+		},
+		Exempt: []netip.Prefix{netip.MustParsePrefix("81.2.69.192/28")},
+		OnBlock: func(_ *http.Request, d AccessDecision) {
+			blocked = append(blocked, d)
+		},
+	})
+	require.NoError(t, err)
+	handler := a.Handler(okHandler)
+
+	w := serve(handler, "81.2.69.160:1234")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	require.Len(t, blocked, 1)
+	assert.Equal(t, "block-gb", blocked[0].Rule.Name)
+	assert.Equal(t, "81.2.69.160", blocked[0].IP.String())
+
+	w = serve(handler, "89.160.20.112:1234")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "block-eu", blocked[1].Rule.Name)
+
+	w = serve(handler, "[2001:218::1]:1234")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "block-asia", blocked[2].Rule.Name)
+
+	w = serve(handler, "1.128.0.1:1234")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "block-as1221", blocked[3].Rule.Name)
+
+	// Exempt, though in GB.
+	w = serve(handler, "81.2.69.200:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+
+	w = serve(handler, "216.160.83.56:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, blocked, 4)
+}
+
+func TestAccessControlAllowList(t *testing.T) {
+	a, err := NewAccessControl(AccessConfig{
+		Country: openReader(t, "GeoIP2-City-Test.mmdb"),
+		Rules: []AccessRule{
+			{Name: "allow-us", Countries: []geoip2.CountryCode{"US"}, Action: geoip2.PolicyAllow},
+		},
+		DefaultAction: geoip2.PolicyBlock,
+		Blocked:       http.RedirectHandler("https://example.com/unavailable", http.StatusFound),
+	})
+	require.NoError(t, err)
+	handler := a.Handler(okHandler)
+
+	w := serve(handler, "216.160.83.56:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(handler, "81.2.69.160:1234")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/unavailable", w.Header().Get("Location"))
+
+	// Not in the database.
+	d := a.Decide(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, d.Blocked())
+	assert.Nil(t, d.Rule)
+}
+
+func TestAccessControlDryRun(t *testing.T) {
+	var blocked []AccessDecision
+	a, err := NewAccessControl(AccessConfig{
+		Country:       openReader(t, "GeoIP2-Country-Test.mmdb"),
+		DefaultAction: geoip2.PolicyBlock,
+		DryRun:        true,
+		OnBlock: func(_ *http.Request, d AccessDecision) {
+			blocked = append(blocked, d)
+		},
+	})
+	require.NoError(t, err)
+	w := serve(a.Handler(okHandler), "81.2.69.160:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, blocked, 1)
+	assert.Equal(t, "81.2.69.160", blocked[0].IP.String())
+
+	_, err = NewAccessControl(AccessConfig{DefaultAction: geoip2.PolicyBlock, DryRun: true})
+	assert.Error(t, err)
+}
+
+func TestAccessControlCodes(t *testing.T) {
+	var blocked []AccessDecision
+	a, err := NewAccessControl(AccessConfig{
+		Country: openReader(t, "GeoIP2-Country-Test.mmdb"),
+		Rules: []AccessRule{
+			{Name: "block-gb", Countries: []geoip2.CountryCode{"gb"}, Action: geoip2.PolicyBlock},
+			{Name: "block-asia", Continents: []geoip2.ContinentCode{"as"}, Action: geoip2.PolicyBlock},
+		},
+		OnBlock: func(_ *http.Request, d AccessDecision) {
+			blocked = append(blocked, d)
+		},
+	})
+	require.NoError(t, err)
+	handler := a.Handler(okHandler)
+
+	assert.Equal(t, http.StatusForbidden, serve(handler, "81.2.69.160:1234").Code)
+	assert.Equal(t, http.StatusForbidden, serve(handler, "[2001:218::1]:1234").Code)
+	require.Len(t, blocked, 2)
+	assert.Equal(t, []geoip2.CountryCode{"GB"}, blocked[0].Rule.Countries)
+
+	// Changing the decision does not change the access control.
+	blocked[0].Rule.Countries[0] = "FR"
+	blocked[0].Rule.Action = geoip2.PolicyAllow
+	assert.Equal(t, http.StatusForbidden, serve(handler, "81.2.69.160:1234").Code)
+
+	for _, rule := range []AccessRule{
+		{Countries: []geoip2.CountryCode{"UK"}},
+		{Continents: []geoip2.ContinentCode{"XX"}},
+	} {
+		_, err := NewAccessControl(AccessConfig{Rules: []AccessRule{rule}})
+		var invalid geoip2.InvalidCodeError
+		assert.ErrorAs(t, err, &invalid, "%+v", rule)
+	}
+}
+
+func TestAccessControlWithMiddleware(t *testing.T) {
+	m := NewMiddleware(Config{
+		Readers: Readers{Country: openReader(t, "GeoIP2-Country-Test.mmdb")},
+	})
+	a, err := NewAccessControl(AccessConfig{
+		Rules: []AccessRule{
+			{Name: "block-gb", Countries: []geoip2.CountryCode{"GB"}, Action: geoip2.PolicyBlock},
+		},
+	})
+	require.NoError(t, err)
+	handler := m.Handler(a.Handler(okHandler))
+
+	w := serve(handler, "81.2.69.160:1234")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,294 @@
+// Package geoip2http provides net/http middleware that geolocates requests
+// using GeoIP2 and GeoLite2 databases.
+package geoip2http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Readers are the readers used to geolocate clients. Lookups are only
+// performed against the readers that are set.
+type Readers struct {
+	// City is used for City lookups. It may be a City or an Enterprise
+	// database.
+	City *geoip2.Reader
+	// Country is used for Country lookups.
+	Country *geoip2.Reader
+	// Enterprise is used for Enterprise lookups.
+	Enterprise *geoip2.Reader
+	// ASN is used for ASN lookups. It may be an ASN or an ISP database.
+	ASN *geoip2.Reader
+	// AnonymousIP is used for AnonymousIP lookups.
+	AnonymousIP *geoip2.Reader
+}
+
+// Lookup geolocates addr using the readers that are set. ctx is passed to
+// the observers of the readers, e.g., through geoip2.Reader.CityContext. The
+// errors of failed lookups are passed to onError, if it is not nil, and their
+// records are left nil.
+func (rs *Readers) Lookup(ctx context.Context, addr netip.Addr, onError func(error)) *Result {
+	ip := net.IP(addr.AsSlice())
+	result := &Result{IP: ip}
+
+	if rs.City != nil {
+		record, err := rs.City.CityContext(ctx, ip)
+		result.City = check(record, err, onError)
+	}
+	if rs.Country != nil {
+		record, err := rs.Country.CountryContext(ctx, ip)
+		result.Country = check(record, err, onError)
+	}
+	if rs.Enterprise != nil {
+		record, err := rs.Enterprise.EnterpriseContext(ctx, ip)
+		result.Enterprise = check(record, err, onError)
+	}
+	if rs.ASN != nil {
+		record, err := rs.ASN.ASNContext(ctx, ip)
+		result.ASN = check(record, err, onError)
+	}
+	if rs.AnonymousIP != nil {
+		record, err := rs.AnonymousIP.AnonymousIPContext(ctx, ip)
+		result.AnonymousIP = check(record, err, onError)
+	}
+	return result
+}
+
+func check[T any](record *T, err error, onError func(error)) *T {
+	if err != nil {
+		if onError != nil {
+			onError(err)
+		}
+		return nil
+	}
+	return record
+}
+
+// Config configures the Middleware.
+type Config struct {
+	Readers
+	// OnError, if set, is called with the errors of failed lookups. The
+	// request is served regardless, without the failed result.
+	OnError func(r *http.Request, err error)
+	// TrustedProxies are the networks of the proxies whose Forwarded and
+	// X-Forwarded-For headers are trusted. See ClientAddr.
+	TrustedProxies []netip.Prefix
+}
+
+// Result holds the results of the lookups for a request. Records are nil
+// if the corresponding reader is not configured or the lookup failed.
+type Result struct {
+	City        *geoip2.City
+	Country     *geoip2.Country
+	Enterprise  *geoip2.Enterprise
+	ASN         *geoip2.ASN
+	AnonymousIP *geoip2.AnonymousIP
+	// IP is the address of the client.
+	IP net.IP
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries result.
+func NewContext(ctx context.Context, result *Result) context.Context {
+	return context.WithValue(ctx, contextKey{}, result)
+}
+
+// FromContext returns the Result stored in ctx by the Middleware, or nil if
+// there is none.
+func FromContext(ctx context.Context) *Result {
+	result, _ := ctx.Value(contextKey{}).(*Result)
+	return result
+}
+
+// ClientIP returns the address of the client stored in ctx, or nil if there
+// is none.
+func ClientIP(ctx context.Context) net.IP {
+	if result := FromContext(ctx); result != nil {
+		return result.IP
+	}
+	return nil
+}
+
+// City returns the City record stored in ctx, or nil if there is none.
+func City(ctx context.Context) *geoip2.City {
+	if result := FromContext(ctx); result != nil {
+		return result.City
+	}
+	return nil
+}
+
+// Country returns the Country record stored in ctx, or nil if there is
+// none.
+func Country(ctx context.Context) *geoip2.Country {
+	if result := FromContext(ctx); result != nil {
+		return result.Country
+	}
+	return nil
+}
+
+// Enterprise returns the Enterprise record stored in ctx, or nil if there
+// is none.
+func Enterprise(ctx context.Context) *geoip2.Enterprise {
+	if result := FromContext(ctx); result != nil {
+		return result.Enterprise
+	}
+	return nil
+}
+
+// ASN returns the ASN record stored in ctx, or nil if there is none.
+func ASN(ctx context.Context) *geoip2.ASN {
+	if result := FromContext(ctx); result != nil {
+		return result.ASN
+	}
+	return nil
+}
+
+// AnonymousIP returns the AnonymousIP record stored in ctx, or nil if there
+// is none.
+func AnonymousIP(ctx context.Context) *geoip2.AnonymousIP {
+	if result := FromContext(ctx); result != nil {
+		return result.AnonymousIP
+	}
+	return nil
+}
+
+// Middleware geolocates the client of each request and stores the Result
+// in the request context, where it can be retrieved with FromContext and
+// the accessor functions.
+type Middleware struct {
+	config Config
+}
+
+// NewMiddleware returns a Middleware using config.
+func NewMiddleware(config Config) *Middleware {
+	return &Middleware{config: config}
+}
+
+// Handler returns a handler that geolocates requests before passing them to
+// next. Requests whose client address cannot be determined are passed on
+// without a Result.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if result := m.Lookup(r); result != nil {
+			r = r.WithContext(NewContext(r.Context(), result))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Lookup geolocates the client of r without modifying it. It returns nil if
+// the client address cannot be determined. The context of r is passed to the
+// observers of the readers, see Readers.Lookup.
+func (m *Middleware) Lookup(r *http.Request) *Result {
+	addr, ok := ClientAddr(r, m.config.TrustedProxies)
+	if !ok {
+		return nil
+	}
+	var onError func(error)
+	if m.config.OnError != nil {
+		onError = func(err error) { m.config.OnError(r, err) }
+	}
+	return m.config.Readers.Lookup(r.Context(), addr, onError)
+}
+
+// ClientAddr returns the address of the client of r.
+//
+// If the peer address of r, r.RemoteAddr, is in one of trustedProxies, the
+// Forwarded header, or the X-Forwarded-For header if there is no Forwarded
+// header, is walked from the right, i.e., from the address added by the
+// nearest proxy, and the first address that is not in trustedProxies is
+// returned. If every address is trusted, the leftmost one is returned. If an
+// entry cannot be parsed, e.g., because it is an obfuscated identifier, the
+// address of the proxy that added it is returned.
+func ClientAddr(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	addr, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !isTrusted(addr, trustedProxies) {
+		return addr, true
+	}
+	return ResolveClientAddr(addr, forwardedFor(r.Header), trustedProxies), true
+}
+
+// ResolveClientAddr returns the address of the client given the address of
+// the peer and the client addresses listed by forwarding proxies, from left
+// to right, as in the X-Forwarded-For header. It implements the walk
+// described in ClientAddr for other protocols, such as gRPC metadata. Hops
+// may include a port.
+func ResolveClientAddr(peer netip.Addr, hops []string, trustedProxies []netip.Prefix) netip.Addr {
+	addr := peer
+	if !isTrusted(addr, trustedProxies) {
+		return addr
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			break
+		}
+		addr = hop
+		if !isTrusted(addr, trustedProxies) {
+			break
+		}
+	}
+	return addr
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor returns the client addresses listed in the Forwarded header
+// or, if there is none, the X-Forwarded-For header, from left to right.
+func forwardedFor(header http.Header) []string {
+	var hops []string
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				// An element without a for parameter is kept as an
+				// unparsable hop so that it stops the walk.
+				hop := ""
+				for _, pair := range strings.Split(element, ";") {
+					name, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+					if strings.EqualFold(name, "for") {
+						hop = strings.Trim(v, `"`)
+					}
+				}
+				hops = append(hops, hop)
+			}
+		}
+		return hops
+	}
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseAddr parses an IP address optionally followed by a port, as found in
+// RemoteAddr and forwarding headers, e.g., "192.0.2.1", "192.0.2.1:80",
+// "2001:db8::1" or "[2001:db8::1]:80".
+func parseAddr(s string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
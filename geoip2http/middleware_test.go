@@ -0,0 +1,179 @@
+package geoip2http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+const testDataDir = "../test-data/test-data/"
+
+func openReader(t *testing.T, name string) *geoip2.Reader {
+	t.Helper()
+	reader, err := geoip2.Open(testDataDir + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
+
+func TestMiddleware(t *testing.T) {
+	m := NewMiddleware(Config{Readers: Readers{
+		City: openReader(t, "GeoIP2-City-Test.mmdb"),
+		ASN:  openReader(t, "GeoLite2-ASN-Test.mmdb"),
+	}})
+
+	var result *Result
+	handler := m.Handler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		result = FromContext(r.Context())
+		if result == nil {
+			return
+		}
+		assert.Equal(t, result.City, City(r.Context()))
+		assert.Equal(t, result.ASN, ASN(r.Context()))
+		assert.Equal(t, result.IP, ClientIP(r.Context()))
+		assert.Nil(t, Country(r.Context()))
+		assert.Nil(t, Enterprise(r.Context()))
+		assert.Nil(t, AnonymousIP(r.Context()))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "81.2.69.160:4711"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.NotNil(t, result)
+	assert.Equal(t, "81.2.69.160", result.IP.String())
+	require.NotNil(t, result.City)
+	assert.Equal(t, "London", result.City.City.Names["en"])
+	require.NotNil(t, result.ASN)
+
+	r.RemoteAddr = "1.128.0.1:4711"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, uint(1221), result.ASN.AutonomousSystemNumber)
+
+	r.RemoteAddr = "@"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Nil(t, result)
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	var errs []error
+	m := NewMiddleware(Config{
+		// The ASN database does not support City lookups.
+		Readers: Readers{City: openReader(t, "GeoLite2-ASN-Test.mmdb")},
+		OnError: func(_ *http.Request, err error) {
+			errs = append(errs, err)
+		},
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "81.2.69.160:4711"
+	result := m.Lookup(r)
+	require.NotNil(t, result)
+	assert.Nil(t, result.City)
+	require.Len(t, errs, 1)
+	var invalid geoip2.InvalidMethodError
+	assert.True(t, errors.As(errs[0], &invalid))
+}
+
+func TestClientAddr(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "192.0.2.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "trusted peer",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"203.0.113.7, 198.51.100.1, 10.0.0.2"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "multiple headers",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1", "10.0.0.3"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "all trusted",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "no header",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "unparsable entry",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1, garbage"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "forwarded",
+			remoteAddr: "[2001:db8::1]:1234",
+			header: http.Header{
+				"Forwarded": {`for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711";by=203.0.113.43`},
+				// Ignored in favor of Forwarded.
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			expected: "192.0.2.60",
+		},
+		{
+			name:       "forwarded with obfuscated identifier",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"Forwarded": {`for=192.0.2.60, for=_hidden, for=10.0.0.5`}},
+			expected:   "10.0.0.5",
+		},
+		{
+			name:       "IPv4-mapped peer",
+			remoteAddr: "[::ffff:10.0.0.1]:1234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			r.Header = test.header
+			addr, ok := ClientAddr(r, trusted)
+			require.True(t, ok)
+			assert.Equal(t, test.expected, addr.String())
+		})
+	}
+}
+
+func TestResolveClientAddr(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	peer := netip.MustParseAddr("10.0.0.1")
+
+	assert.Equal(t,
+		netip.MustParseAddr("198.51.100.1"),
+		ResolveClientAddr(peer, []string{"203.0.113.7", " 198.51.100.1:80", "10.0.0.2"}, trusted),
+	)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.2"), ResolveClientAddr(peer, []string{"unknown", "10.0.0.2"}, trusted))
+	assert.Equal(t, peer, ResolveClientAddr(peer, nil, trusted))
+
+	untrusted := netip.MustParseAddr("192.0.2.1")
+	assert.Equal(t, untrusted, ResolveClientAddr(untrusted, []string{"198.51.100.1"}, trusted))
+}
@@ -0,0 +1,275 @@
+// Package geoip2metrics collects metrics about the lookups performed by
+// geoip2 Readers and exposes them in the Prometheus text format, without
+// depending on the Prometheus client libraries.
+//
+// The metrics are:
+//
+//	geoip2_lookups_total                     counter    lookups by method and database type
+//	geoip2_lookup_not_found_total            counter    lookups without a record
+//	geoip2_lookup_errors_total               counter    lookups that returned an error
+//	geoip2_lookup_duration_seconds           histogram  duration of lookups
+//	geoip2_database_build_timestamp_seconds  gauge      build time of the databases
+//	geoip2_database_build_age_seconds        gauge      time since the databases were built
+//
+// The lookup metrics have method and database_type labels, e.g.,
+// method="City" and database_type="GeoIP2-City". The database metrics have
+// a database_type label.
+package geoip2metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the
+// duration histogram when Config.Buckets is nil. They range from one
+// microsecond to ten milliseconds, as lookups in memory-mapped databases
+// are fast.
+var DefaultBuckets = []float64{
+	0.000001, 0.0000025, 0.000005,
+	0.00001, 0.000025, 0.00005,
+	0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005,
+	0.01,
+}
+
+// Config configures Metrics.
+type Config struct {
+	// Buckets are the upper bounds, in seconds, of the buckets of the
+	// duration histogram, in increasing order. If nil, DefaultBuckets is
+	// used.
+	Buckets []float64
+}
+
+// Metrics is a geoip2.Observer that collects metrics about lookups. It is
+// safe for concurrent use.
+type Metrics struct {
+	series    map[seriesKey]*series
+	databases map[string]uint
+	buckets   []float64
+	mu        sync.RWMutex
+}
+
+type seriesKey struct {
+	method       string
+	databaseType string
+}
+
+// series holds the metrics of the lookups of one method in one type of
+// database.
+type series struct {
+	// buckets holds the number of lookups in each bucket, not cumulatively.
+	// The last element counts the lookups above the largest bound.
+	buckets  []atomic.Uint64
+	lookups  atomic.Uint64
+	notFound atomic.Uint64
+	errors   atomic.Uint64
+	// nanoseconds is the sum of the durations of the lookups.
+	nanoseconds atomic.Int64
+}
+
+// NewMetrics returns Metrics using config.
+func NewMetrics(config Config) *Metrics {
+	if config.Buckets == nil {
+		config.Buckets = DefaultBuckets
+	}
+	return &Metrics{
+		series:    map[seriesKey]*series{},
+		databases: map[string]uint{},
+		buckets:   slices.Clone(config.Buckets),
+	}
+}
+
+// Instrument adds m to the observers of reader and records the build time
+// of its database. As the build time is recorded by database type, instrumenting
+// a newer release of a database, e.g., after reloading it, replaces the
+// build time of the previous one.
+//
+// Instrument must not be called concurrently with lookups in reader.
+func (m *Metrics) Instrument(reader *geoip2.Reader) {
+	metadata := reader.Metadata()
+
+	m.mu.Lock()
+	m.databases[metadata.DatabaseType] = metadata.BuildEpoch
+	m.mu.Unlock()
+
+	reader.SetObserver(geoip2.MultiObserver(reader.Observer(), m))
+}
+
+// ObserveLookup records event. It implements geoip2.Observer.
+func (m *Metrics) ObserveLookup(_ context.Context, event geoip2.LookupEvent) {
+	s := m.seriesFor(seriesKey{event.Method, event.DatabaseType})
+
+	s.lookups.Add(1)
+	if event.Err != nil {
+		s.errors.Add(1)
+	} else if !event.Found {
+		s.notFound.Add(1)
+	}
+	s.nanoseconds.Add(int64(event.Duration))
+	seconds := event.Duration.Seconds()
+	i, _ := slices.BinarySearch(m.buckets, seconds)
+	s.buckets[i].Add(1)
+}
+
+func (m *Metrics) seriesFor(key seriesKey) *series {
+	m.mu.RLock()
+	s := m.series[key]
+	m.mu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s = m.series[key]; s == nil {
+		s = &series{buckets: make([]atomic.Uint64, len(m.buckets)+1)}
+		m.series[key] = s
+	}
+	return s
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format. It allows m
+// to be registered as the handler of, e.g., /metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format. It
+// implements io.WriterTo.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.RLock()
+	keys := make([]seriesKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b seriesKey) int {
+		if c := strings.Compare(a.method, b.method); c != 0 {
+			return c
+		}
+		return strings.Compare(a.databaseType, b.databaseType)
+	})
+	all := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		all = append(all, m.series[key])
+	}
+	databaseTypes := make([]string, 0, len(m.databases))
+	for databaseType := range m.databases {
+		databaseTypes = append(databaseTypes, databaseType)
+	}
+	slices.Sort(databaseTypes)
+	buildEpochs := make([]uint, 0, len(databaseTypes))
+	for _, databaseType := range databaseTypes {
+		buildEpochs = append(buildEpochs, m.databases[databaseType])
+	}
+	m.mu.RUnlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	counters := []struct {
+		value func(*series) uint64
+		name  string
+		help  string
+	}{
+		{
+			func(s *series) uint64 { return s.lookups.Load() },
+			"geoip2_lookups_total", "Lookups performed by geoip2 Readers.",
+		},
+		{
+			func(s *series) uint64 { return s.notFound.Load() },
+			"geoip2_lookup_not_found_total", "Lookups of IP addresses that are not in the database.",
+		},
+		{
+			func(s *series) uint64 { return s.errors.Load() },
+			"geoip2_lookup_errors_total", "Lookups that returned an error.",
+		},
+	}
+	for _, counter := range counters {
+		writeHeader(cw, counter.name, "counter", counter.help)
+		for i, s := range all {
+			fmt.Fprintf(cw, "%s{%s} %d\n", counter.name, lookupLabels(keys[i]), counter.value(s))
+		}
+	}
+
+	const duration = "geoip2_lookup_duration_seconds"
+	writeHeader(cw, duration, "histogram", "Duration of lookups performed by geoip2 Readers.")
+	for i, s := range all {
+		labels := lookupLabels(keys[i])
+		var cumulative uint64
+		for j, bound := range m.buckets {
+			cumulative += s.buckets[j].Load()
+			fmt.Fprintf(cw, "%s_bucket{%s,le=%q} %d\n", duration, labels, formatFloat(bound), cumulative)
+		}
+		cumulative += s.buckets[len(m.buckets)].Load()
+		fmt.Fprintf(cw, "%s_bucket{%s,le=\"+Inf\"} %d\n", duration, labels, cumulative)
+		fmt.Fprintf(cw, "%s_sum{%s} %s\n", duration, labels,
+			formatFloat(time.Duration(s.nanoseconds.Load()).Seconds()))
+		fmt.Fprintf(cw, "%s_count{%s} %d\n", duration, labels, cumulative)
+	}
+
+	now := time.Now()
+	const timestamp = "geoip2_database_build_timestamp_seconds"
+	writeHeader(cw, timestamp, "gauge", "Time the databases were built, in seconds since the epoch.")
+	for i, databaseType := range databaseTypes {
+		fmt.Fprintf(cw, "%s{database_type=\"%s\"} %d\n", timestamp, escape(databaseType), buildEpochs[i])
+	}
+	const age = "geoip2_database_build_age_seconds"
+	writeHeader(cw, age, "gauge", "Time since the databases were built.")
+	for i, databaseType := range databaseTypes {
+		built := time.Unix(int64(buildEpochs[i]), 0)
+		fmt.Fprintf(cw, "%s{database_type=\"%s\"} %s\n", age, escape(databaseType),
+			formatFloat(now.Sub(built).Seconds()))
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func lookupLabels(key seriesKey) string {
+	return fmt.Sprintf("method=\"%s\",database_type=\"%s\"", escape(key.method), escape(key.databaseType))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape escapes a label value for the Prometheus text format.
+func escape(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written to w and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	err error
+	n   int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
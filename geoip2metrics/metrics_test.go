@@ -0,0 +1,125 @@
+package geoip2metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+const testDataDir = "../test-data/test-data/"
+
+// metricLines returns the samples written by m, without comments, keyed by
+// metric name and labels.
+func metricLines(t *testing.T, m *Metrics) map[string]string {
+	t.Helper()
+
+	var out strings.Builder
+	n, err := m.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+
+	samples := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		require.Positive(t, i, line)
+		samples[line[:i]] = line[i+1:]
+	}
+	return samples
+}
+
+func TestInstrument(t *testing.T) {
+	reader, err := geoip2.Open(testDataDir + "GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	m := NewMetrics(Config{})
+	m.Instrument(reader)
+
+	for _, ip := range []string{"81.2.69.160", "2001:218::", "10.0.0.1"} {
+		_, err := reader.City(net.ParseIP(ip))
+		require.NoError(t, err)
+	}
+	_, err = reader.City(nil)
+	require.Error(t, err)
+	_, err = reader.Country(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	samples := metricLines(t, m)
+	city := `method="City",database_type="GeoIP2-City"`
+	country := `method="Country",database_type="GeoIP2-City"`
+
+	assert.Equal(t, "4", samples["geoip2_lookups_total{"+city+"}"])
+	assert.Equal(t, "1", samples["geoip2_lookup_not_found_total{"+city+"}"])
+	assert.Equal(t, "1", samples["geoip2_lookup_errors_total{"+city+"}"])
+	assert.Equal(t, "4", samples["geoip2_lookup_duration_seconds_count{"+city+"}"])
+	assert.Equal(t, "4", samples["geoip2_lookup_duration_seconds_bucket{"+city+`,le="+Inf"}`])
+	assert.Equal(t, "1", samples["geoip2_lookups_total{"+country+"}"])
+	assert.Equal(t, "0", samples["geoip2_lookup_not_found_total{"+country+"}"])
+
+	buildEpoch := reader.Metadata().BuildEpoch
+	assert.Equal(t,
+		strconv.FormatUint(uint64(buildEpoch), 10),
+		samples[`geoip2_database_build_timestamp_seconds{database_type="GeoIP2-City"}`],
+	)
+	age, err := strconv.ParseFloat(samples[`geoip2_database_build_age_seconds{database_type="GeoIP2-City"}`], 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Since(time.Unix(int64(buildEpoch), 0)).Seconds(), age, 60)
+}
+
+func TestHistogram(t *testing.T) {
+	ctx := context.Background()
+	m := NewMetrics(Config{Buckets: []float64{0.001, 0.01}})
+
+	for _, d := range []time.Duration{
+		500 * time.Microsecond,
+		time.Millisecond,
+		5 * time.Millisecond,
+		time.Second,
+	} {
+		m.ObserveLookup(ctx, geoip2.LookupEvent{Method: "ASN", DatabaseType: "GeoLite2-ASN", Duration: d, Found: true})
+	}
+	m.ObserveLookup(ctx, geoip2.LookupEvent{Method: "ASN", DatabaseType: "GeoLite2-ASN", Err: errors.New("oops")})
+
+	samples := metricLines(t, m)
+	labels := `method="ASN",database_type="GeoLite2-ASN"`
+	assert.Equal(t, "3", samples["geoip2_lookup_duration_seconds_bucket{"+labels+`,le="0.001"}`])
+	assert.Equal(t, "4", samples["geoip2_lookup_duration_seconds_bucket{"+labels+`,le="0.01"}`])
+	assert.Equal(t, "5", samples["geoip2_lookup_duration_seconds_bucket{"+labels+`,le="+Inf"}`])
+	assert.Equal(t, "1.0065", samples["geoip2_lookup_duration_seconds_sum{"+labels+"}"])
+	assert.Equal(t, "5", samples["geoip2_lookups_total{"+labels+"}"])
+	assert.Equal(t, "1", samples["geoip2_lookup_errors_total{"+labels+"}"])
+	assert.Equal(t, "0", samples["geoip2_lookup_not_found_total{"+labels+"}"])
+}
+
+func TestServeHTTP(t *testing.T) {
+	m := NewMetrics(Config{})
+	m.ObserveLookup(context.Background(), geoip2.LookupEvent{
+		Method:       "City",
+		DatabaseType: `odd "type"`,
+		Found:        true,
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE geoip2_lookups_total counter\n")
+	assert.Contains(t, body, "# TYPE geoip2_lookup_duration_seconds histogram\n")
+	assert.Contains(t, body, `geoip2_lookups_total{method="City",database_type="odd \"type\""} 1`+"\n")
+}
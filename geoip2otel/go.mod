@@ -0,0 +1,30 @@
+module github.com/oschwald/geoip2-golang/geoip2otel
+
+go 1.23.0
+
+require (
+	github.com/oschwald/geoip2-golang v1.13.0
+	github.com/stretchr/testify v1.10.0
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/sdk v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/oschwald/maxminddb-golang v1.13.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// Use the root module from this repository until it is released with the
+// APIs used by this module (the Observer and the Context lookup methods).
+// TODO: once the root module is tagged, require that version above and
+// drop this replace, which Go ignores when this module is a dependency.
+replace github.com/oschwald/geoip2-golang => ../
@@ -0,0 +1,86 @@
+// Package geoip2otel records the lookups performed by geoip2 Readers as
+// OpenTelemetry spans.
+//
+// Each lookup is recorded as a span named after its method, e.g.,
+// "geoip2.City", with the attributes geoip2.method, geoip2.database_type and
+// geoip2.found. Failed lookups have an error status and record the error.
+// Use the Context variants of the lookup methods, e.g., Reader.CityContext,
+// to make the spans children of the span of, e.g., the request being served:
+//
+//	observer := geoip2otel.NewObserver(geoip2otel.Config{})
+//	observer.Instrument(db)
+//	record, err := db.CityContext(ctx, ip)
+package geoip2otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// ScopeName is the instrumentation scope name of the tracer used by an
+// Observer.
+const ScopeName = "github.com/oschwald/geoip2-golang/geoip2otel"
+
+// The attributes of lookup spans.
+const (
+	// MethodKey is the name of the lookup method, e.g., "City".
+	MethodKey = attribute.Key("geoip2.method")
+	// DatabaseTypeKey is the database type of the Reader, e.g.,
+	// "GeoIP2-City".
+	DatabaseTypeKey = attribute.Key("geoip2.database_type")
+	// FoundKey reports whether the database had a record for the IP
+	// address.
+	FoundKey = attribute.Key("geoip2.found")
+)
+
+// Config configures an Observer.
+type Config struct {
+	// TracerProvider provides the tracer used to record spans. If nil, the
+	// global TracerProvider is used.
+	TracerProvider trace.TracerProvider
+}
+
+// Observer is a geoip2.Observer that records lookups as spans. It is safe
+// for concurrent use.
+type Observer struct {
+	tracer trace.Tracer
+}
+
+// NewObserver returns an Observer using config.
+func NewObserver(config Config) *Observer {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	return &Observer{tracer: config.TracerProvider.Tracer(ScopeName)}
+}
+
+// Instrument adds o to the observers of reader. Instrument must not be
+// called concurrently with lookups in reader.
+func (o *Observer) Instrument(reader *geoip2.Reader) {
+	reader.SetObserver(geoip2.MultiObserver(reader.Observer(), o))
+}
+
+// ObserveLookup records event as a span that is a child of the span in ctx,
+// if any. It implements geoip2.Observer.
+func (o *Observer) ObserveLookup(ctx context.Context, event geoip2.LookupEvent) {
+	_, span := o.tracer.Start(ctx, "geoip2."+event.Method,
+		trace.WithTimestamp(event.Start),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			MethodKey.String(event.Method),
+			DatabaseTypeKey.String(event.DatabaseType),
+			FoundKey.Bool(event.Found),
+		),
+	)
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End(trace.WithTimestamp(event.Start.Add(event.Duration)))
+}
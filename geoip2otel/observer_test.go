@@ -0,0 +1,88 @@
+package geoip2otel
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+const testDataDir = "../test-data/test-data/"
+
+func TestObserver(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	reader, err := geoip2.Open(testDataDir + "GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	NewObserver(Config{TracerProvider: provider}).Instrument(reader)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	_, err = reader.CityContext(ctx, net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	parent.End()
+
+	_, err = reader.Country(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	_, err = reader.City(nil)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	city := spans[0]
+	assert.Equal(t, "geoip2.City", city.Name())
+	assert.Equal(t, trace.SpanKindInternal, city.SpanKind())
+	assert.Equal(t, ScopeName, city.InstrumentationScope().Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), city.Parent().SpanID())
+	assert.Equal(t, parent.SpanContext().TraceID(), city.SpanContext().TraceID())
+	assert.False(t, city.EndTime().Before(city.StartTime()))
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		MethodKey.String("City"),
+		DatabaseTypeKey.String("GeoIP2-City"),
+		FoundKey.Bool(true),
+	}, city.Attributes())
+	assert.Equal(t, codes.Unset, city.Status().Code)
+
+	assert.Equal(t, "request", spans[1].Name())
+
+	country := spans[2]
+	assert.Equal(t, "geoip2.Country", country.Name())
+	assert.False(t, country.Parent().IsValid())
+	assert.Contains(t, country.Attributes(), FoundKey.Bool(false))
+
+	failed := spans[3]
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	require.Len(t, failed.Events(), 1)
+	assert.Equal(t, "exception", failed.Events()[0].Name)
+}
+
+func TestInstrumentKeepsObservers(t *testing.T) {
+	reader, err := geoip2.Open(testDataDir + "GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	first := tracetest.NewSpanRecorder()
+	second := tracetest.NewSpanRecorder()
+	NewObserver(Config{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(first))}).
+		Instrument(reader)
+	NewObserver(Config{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(second))}).
+		Instrument(reader)
+
+	_, err = reader.ASN(net.ParseIP("1.128.0.0"))
+	require.NoError(t, err)
+
+	assert.Len(t, first.Ended(), 1)
+	assert.Len(t, second.Ended(), 1)
+}
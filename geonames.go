@@ -0,0 +1,192 @@
+package geoip2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GeoName is a place from the GeoNames database, as found in its
+// allCountries.txt and citiesN.txt dumps.
+type GeoName struct {
+	AlternateNames []string
+	Name           string
+	ASCIIName      string
+	FeatureClass   string
+	FeatureCode    string
+	CountryCode    CountryCode
+	Admin1Code     string
+	Admin2Code     string
+	Admin3Code     string
+	Admin4Code     string
+	TimeZone       string
+	Latitude       float64
+	Longitude      float64
+	Population     uint64
+	Elevation      int
+	GeoNameID      uint
+}
+
+// GeoNameSource provides GeoNames places by GeoNameID. GeoNames implements
+// it; other implementations may, e.g., query a database.
+type GeoNameSource interface {
+	// GeoName returns the place with the given GeoNameID or nil if it is
+	// unknown.
+	GeoName(geoNameID uint) *GeoName
+}
+
+// GeoNames is an in-memory GeoNameSource loaded from a GeoNames dump.
+type GeoNames struct {
+	places map[uint]*GeoName
+}
+
+// LoadGeoNames loads a GeoNames dump in the tab-separated format of
+// allCountries.txt and citiesN.txt from r. If keep is not nil, only the
+// places for which it returns true are kept, which is useful to limit the
+// memory used by the larger dumps.
+func LoadGeoNames(r io.Reader, keep func(*GeoName) bool) (*GeoNames, error) {
+	g := &GeoNames{places: map[uint]*GeoName{}}
+
+	scanner := bufio.NewScanner(r)
+	// Lines with many alternate names exceed bufio.MaxScanTokenSize.
+	scanner.Buffer(nil, 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if scanner.Text() == "" {
+			continue
+		}
+		place, err := parseGeoName(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("geoip2: GeoNames line %d: %w", line, err)
+		}
+		if keep == nil || keep(place) {
+			g.places[place.GeoNameID] = place
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("geoip2: reading GeoNames: %w", err)
+	}
+	return g, nil
+}
+
+// LoadGeoNamesFile loads the GeoNames dump at path. See LoadGeoNames.
+func LoadGeoNamesFile(path string, keep func(*GeoName) bool) (*GeoNames, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadGeoNames(f, keep)
+}
+
+// GeoName returns the place with the given GeoNameID or nil if it is
+// unknown.
+func (g *GeoNames) GeoName(geoNameID uint) *GeoName {
+	return g.places[geoNameID]
+}
+
+// Len returns the number of places loaded.
+func (g *GeoNames) Len() int {
+	return len(g.places)
+}
+
+// GeoNamesEnrichment holds the GeoNames places referenced by a record. A
+// field is nil if the record lacks the corresponding GeoNameID or the
+// source does not know it.
+type GeoNamesEnrichment struct {
+	Continent *GeoName
+	Country   *GeoName
+	City      *GeoName
+	// Subdivisions has one element for each subdivision of the record, in
+	// the same order.
+	Subdivisions []*GeoName
+}
+
+// EnrichCity returns the GeoNames places referenced by record.
+func EnrichCity(source GeoNameSource, record *City) GeoNamesEnrichment {
+	e := GeoNamesEnrichment{
+		Continent:    lookupGeoName(source, record.Continent.GeoNameID),
+		Country:      lookupGeoName(source, record.Country.GeoNameID),
+		City:         lookupGeoName(source, record.City.GeoNameID),
+		Subdivisions: make([]*GeoName, len(record.Subdivisions)),
+	}
+	for i, sub := range record.Subdivisions {
+		e.Subdivisions[i] = lookupGeoName(source, sub.GeoNameID)
+	}
+	return e
+}
+
+// EnrichEnterprise returns the GeoNames places referenced by record.
+func EnrichEnterprise(source GeoNameSource, record *Enterprise) GeoNamesEnrichment {
+	e := GeoNamesEnrichment{
+		Continent:    lookupGeoName(source, record.Continent.GeoNameID),
+		Country:      lookupGeoName(source, record.Country.GeoNameID),
+		City:         lookupGeoName(source, record.City.GeoNameID),
+		Subdivisions: make([]*GeoName, len(record.Subdivisions)),
+	}
+	for i, sub := range record.Subdivisions {
+		e.Subdivisions[i] = lookupGeoName(source, sub.GeoNameID)
+	}
+	return e
+}
+
+func lookupGeoName(source GeoNameSource, geoNameID uint) *GeoName {
+	if geoNameID == 0 {
+		return nil
+	}
+	return source.GeoName(geoNameID)
+}
+
+// geoNameColumns is the number of columns in a GeoNames dump.
+const geoNameColumns = 19
+
+func parseGeoName(line string) (*GeoName, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != geoNameColumns {
+		return nil, fmt.Errorf("expected %d columns, got %d", geoNameColumns, len(fields))
+	}
+
+	id, err := strconv.ParseUint(fields[0], 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid geonameid %q: %w", fields[0], err)
+	}
+	place := &GeoName{
+		GeoNameID:    uint(id),
+		Name:         fields[1],
+		ASCIIName:    fields[2],
+		FeatureClass: fields[6],
+		FeatureCode:  fields[7],
+		CountryCode:  CountryCode(fields[8]),
+		Admin1Code:   fields[10],
+		Admin2Code:   fields[11],
+		Admin3Code:   fields[12],
+		Admin4Code:   fields[13],
+		TimeZone:     fields[17],
+	}
+	if fields[3] != "" {
+		place.AlternateNames = strings.Split(fields[3], ",")
+	}
+
+	if place.Latitude, err = strconv.ParseFloat(fields[4], 64); err != nil {
+		return nil, fmt.Errorf("invalid latitude %q: %w", fields[4], err)
+	}
+	if place.Longitude, err = strconv.ParseFloat(fields[5], 64); err != nil {
+		return nil, fmt.Errorf("invalid longitude %q: %w", fields[5], err)
+	}
+	if fields[14] != "" {
+		if place.Population, err = strconv.ParseUint(fields[14], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid population %q: %w", fields[14], err)
+		}
+	}
+	if fields[15] != "" {
+		if place.Elevation, err = strconv.Atoi(fields[15]); err != nil {
+			return nil, fmt.Errorf("invalid elevation %q: %w", fields[15], err)
+		}
+	}
+	return place, nil
+}
@@ -0,0 +1,110 @@
+package geoip2
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGeoNames = "" +
+	"2643743\tLondon\tLondon\tLondinium,Londra,Londres\t51.50853\t-0.12574\t" +
+	"P\tPPLC\tGB\t\tENG\tGLA\t\t\t8961989\t\t25\tEurope/London\t2024-01-01\n" +
+	"6269131\tEngland\tEngland\tAngleterre,Inglaterra\t52.16045\t-0.70312\t" +
+	"A\tADM1\tGB\t\tENG\t\t\t\t57106398\t\t138\tEurope/London\t2024-01-01\n" +
+	"2635167\tUnited Kingdom\tUnited Kingdom\tUK,Royaume-Uni\t54.75844\t-2.69531\t" +
+	"A\tPCLI\tGB\t\t00\t\t\t\t66488991\t\t263\tEurope/London\t2024-01-01\n" +
+	"\n" +
+	"5803556\tMilton\tMilton\t\t47.24816\t-122.31290\t" +
+	"P\tPPL\tUS\t\tWA\t053\t\t\t8697\t15\t17\tAmerica/Los_Angeles\t2024-01-01\n"
+
+func TestLoadGeoNames(t *testing.T) {
+	g, err := LoadGeoNames(strings.NewReader(testGeoNames), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, g.Len())
+
+	london := g.GeoName(2643743)
+	require.NotNil(t, london)
+	assert.Equal(t, &GeoName{
+		AlternateNames: []string{"Londinium", "Londra", "Londres"},
+		Name:           "London",
+		ASCIIName:      "London",
+		FeatureClass:   "P",
+		FeatureCode:    "PPLC",
+		CountryCode:    "GB",
+		Admin1Code:     "ENG",
+		Admin2Code:     "GLA",
+		TimeZone:       "Europe/London",
+		Latitude:       51.50853,
+		Longitude:      -0.12574,
+		Population:     8961989,
+		GeoNameID:      2643743,
+	}, london)
+
+	milton := g.GeoName(5803556)
+	require.NotNil(t, milton)
+	assert.Nil(t, milton.AlternateNames)
+	assert.Equal(t, 15, milton.Elevation)
+
+	assert.Nil(t, g.GeoName(1))
+
+	g, err = LoadGeoNames(strings.NewReader(testGeoNames), func(place *GeoName) bool {
+		return place.FeatureClass == "P" && place.Population > 10000
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.Len())
+	assert.NotNil(t, g.GeoName(2643743))
+}
+
+func TestLoadGeoNamesErrors(t *testing.T) {
+	_, err := LoadGeoNames(strings.NewReader("1\tToo\tShort\n"), nil)
+	assert.EqualError(t, err, "geoip2: GeoNames line 1: expected 19 columns, got 3")
+
+	dump := strings.Replace(testGeoNames, "8961989", "many", 1)
+	_, err = LoadGeoNames(strings.NewReader("\n"+dump), nil)
+	assert.ErrorContains(t, err, `geoip2: GeoNames line 2: invalid population "many"`)
+}
+
+func TestEnrichCity(t *testing.T) {
+	g, err := LoadGeoNames(strings.NewReader(testGeoNames), nil)
+	require.NoError(t, err)
+
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	e := EnrichCity(g, record)
+	require.NotNil(t, e.City)
+	assert.Equal(t, uint64(8961989), e.City.Population)
+	require.NotNil(t, e.Country)
+	assert.Equal(t, "United Kingdom", e.Country.Name)
+	require.Len(t, e.Subdivisions, len(record.Subdivisions))
+	require.NotNil(t, e.Subdivisions[0])
+	assert.Equal(t, "ENG", e.Subdivisions[0].Admin1Code)
+	// The continent is not in the dump.
+	assert.Nil(t, e.Continent)
+
+	record, err = reader.City(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Equal(t, GeoNamesEnrichment{Subdivisions: []*GeoName{}}, EnrichCity(g, record))
+}
+
+func TestEnrichEnterprise(t *testing.T) {
+	g, err := LoadGeoNames(strings.NewReader(testGeoNames), nil)
+	require.NoError(t, err)
+
+	var record Enterprise
+	record.City.GeoNameID = 5803556
+	record.Subdivisions = []EnterpriseSubdivision{{GeoNameID: 6269131}, {GeoNameID: 42}}
+
+	e := EnrichEnterprise(g, &record)
+	require.NotNil(t, e.City)
+	assert.Equal(t, "Milton", e.City.Name)
+	assert.Nil(t, e.Country)
+	assert.Equal(t, []*GeoName{g.GeoName(6269131), nil}, e.Subdivisions)
+}
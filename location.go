@@ -0,0 +1,131 @@
+package geoip2
+
+import (
+	"math"
+)
+
+// EarthRadius is the mean radius of the Earth in kilometers, as used for
+// the distance calculations in this package.
+const EarthRadius = 6371.0088
+
+// HasCoordinates reports whether the location has coordinates. Records
+// for which the database has no location data, such as those for anycast
+// networks, do not.
+func (l Location) HasCoordinates() bool {
+	return l.Latitude != 0 || l.Longitude != 0 || l.AccuracyRadius != 0
+}
+
+// DistanceTo returns the great-circle distance in kilometers between the
+// coordinates of l and other. The accuracy radii are not taken into
+// account. The result is meaningless if either location lacks coordinates.
+func (l Location) DistanceTo(other Location) float64 {
+	return l.DistanceToPoint(other.Latitude, other.Longitude)
+}
+
+// DistanceToPoint returns the great-circle distance in kilometers between
+// the coordinates of l and the point at latitude and longitude, given in
+// degrees. The accuracy radius is not taken into account.
+func (l Location) DistanceToPoint(latitude, longitude float64) float64 {
+	return haversine(l.Latitude, l.Longitude, latitude, longitude)
+}
+
+// MinDistanceTo returns the smallest distance in kilometers that may
+// separate l and other, taking their accuracy radii into account. It is
+// zero if the areas described by the two locations overlap.
+func (l Location) MinDistanceTo(other Location) float64 {
+	d := l.DistanceTo(other) - float64(l.AccuracyRadius) - float64(other.AccuracyRadius)
+	return max(d, 0)
+}
+
+// MaxDistanceTo returns the largest distance in kilometers that may
+// separate l and other, taking their accuracy radii into account.
+func (l Location) MaxDistanceTo(other Location) float64 {
+	return l.DistanceTo(other) + float64(l.AccuracyRadius) + float64(other.AccuracyRadius)
+}
+
+// MayBeWithin reports whether the location may be within radius kilometers
+// of the point at latitude and longitude, i.e., whether any part of the
+// area described by its coordinates and accuracy radius is.
+func (l Location) MayBeWithin(latitude, longitude, radius float64) bool {
+	return l.DistanceToPoint(latitude, longitude)-float64(l.AccuracyRadius) <= radius
+}
+
+// IsWithin reports whether the location is certainly within radius
+// kilometers of the point at latitude and longitude, i.e., whether the
+// whole area described by its coordinates and accuracy radius is.
+func (l Location) IsWithin(latitude, longitude, radius float64) bool {
+	return l.DistanceToPoint(latitude, longitude)+float64(l.AccuracyRadius) <= radius
+}
+
+// InBoundingBox reports whether the coordinates of l are within box. The
+// accuracy radius is not taken into account.
+func (l Location) InBoundingBox(box BoundingBox) bool {
+	return box.Contains(l.Latitude, l.Longitude)
+}
+
+// BoundingBox is an area delimited by two latitudes and two longitudes, in
+// degrees. If MinLongitude is greater than MaxLongitude, the box crosses
+// the antimeridian.
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// BoundingBoxAround returns the smallest BoundingBox containing every point
+// within radius kilometers of the point at latitude and longitude.
+func BoundingBoxAround(latitude, longitude, radius float64) BoundingBox {
+	angular := radius / EarthRadius
+	lat := latitude * math.Pi / 180
+	lon := longitude * math.Pi / 180
+
+	minLat := lat - angular
+	maxLat := lat + angular
+	var minLon, maxLon float64
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		deltaLon := math.Asin(math.Sin(angular) / math.Cos(lat))
+		minLon = lon - deltaLon
+		if minLon < -math.Pi {
+			minLon += 2 * math.Pi
+		}
+		maxLon = lon + deltaLon
+		if maxLon > math.Pi {
+			maxLon -= 2 * math.Pi
+		}
+	} else {
+		// The circle contains a pole, so every longitude is included.
+		minLat = max(minLat, -math.Pi/2)
+		maxLat = min(maxLat, math.Pi/2)
+		minLon = -math.Pi
+		maxLon = math.Pi
+	}
+
+	return BoundingBox{
+		MinLatitude:  minLat * 180 / math.Pi,
+		MinLongitude: minLon * 180 / math.Pi,
+		MaxLatitude:  maxLat * 180 / math.Pi,
+		MaxLongitude: maxLon * 180 / math.Pi,
+	}
+}
+
+// Contains reports whether the point at latitude and longitude is within
+// the box.
+func (b BoundingBox) Contains(latitude, longitude float64) bool {
+	if latitude < b.MinLatitude || latitude > b.MaxLatitude {
+		return false
+	}
+	if b.MinLongitude <= b.MaxLongitude {
+		return longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+	}
+	return longitude >= b.MinLongitude || longitude <= b.MaxLongitude
+}
+
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(min(a, 1)))
+}
@@ -0,0 +1,67 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationDistance(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	london, err := reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	milton, err := reader.City(net.ParseIP("216.160.83.56"))
+	require.NoError(t, err)
+
+	require.True(t, london.Location.HasCoordinates())
+	assert.InDelta(t, 7732.34, london.Location.DistanceTo(milton.Location), 0.01)
+	assert.InDelta(t, 7732.34, milton.Location.DistanceTo(london.Location), 0.01)
+	assert.InDelta(t, 342.94, london.Location.DistanceToPoint(48.8566, 2.3522), 0.01)
+	assert.Zero(t, london.Location.DistanceTo(london.Location))
+
+	anycast, err := reader.City(net.ParseIP("214.1.1.0"))
+	require.NoError(t, err)
+	assert.False(t, anycast.Location.HasCoordinates())
+}
+
+func TestLocationAccuracy(t *testing.T) {
+	london := Location{Latitude: 51.5142, Longitude: -0.0931, AccuracyRadius: 100}
+	paris := Location{Latitude: 48.8566, Longitude: 2.3522, AccuracyRadius: 50}
+
+	assert.InDelta(t, 192.94, london.MinDistanceTo(paris), 0.01)
+	assert.InDelta(t, 492.94, london.MaxDistanceTo(paris), 0.01)
+
+	paris.AccuracyRadius = 500
+	assert.Zero(t, london.MinDistanceTo(paris))
+
+	assert.True(t, london.MayBeWithin(48.8566, 2.3522, 250))
+	assert.False(t, london.IsWithin(48.8566, 2.3522, 250))
+	assert.True(t, london.IsWithin(48.8566, 2.3522, 450))
+	assert.False(t, london.MayBeWithin(48.8566, 2.3522, 200))
+}
+
+func TestBoundingBox(t *testing.T) {
+	london := Location{Latitude: 51.5142, Longitude: -0.0931}
+
+	box := BoundingBoxAround(48.8566, 2.3522, 400)
+	assert.True(t, london.InBoundingBox(box))
+	assert.True(t, box.Contains(48.8566, 2.3522))
+	assert.False(t, BoundingBoxAround(48.8566, 2.3522, 250).Contains(london.Latitude, london.Longitude))
+
+	// A box around Fiji crosses the antimeridian.
+	fiji := BoundingBoxAround(-17.7134, 179.9, 100)
+	assert.Greater(t, fiji.MinLongitude, fiji.MaxLongitude)
+	assert.True(t, fiji.Contains(-17.7, -179.9))
+	assert.True(t, fiji.Contains(-17.7, 179.5))
+	assert.False(t, fiji.Contains(-17.7, 0))
+
+	// A box around the North Pole includes every longitude.
+	pole := BoundingBoxAround(89.9, 0, 100)
+	assert.InDelta(t, 90, pole.MaxLatitude, 1e-9)
+	assert.True(t, pole.Contains(89.5, 180))
+}
@@ -0,0 +1,315 @@
+package geoip2
+
+import "log/slog"
+
+// LogDetail controls how much of a record is included in its slog.Value.
+//
+// The LogValue and LogValueDetail methods of the record types return a group
+// whose attributes use the same keys for the same data across records, e.g.,
+// "country" for the ISO code of the country and "anonymizer" for the
+// anonymizer flags that are set, named like the corresponding RiskSignals.
+// Empty and false values are omitted, so
+//
+//	slog.Info("request", "geo", record)
+//
+// only logs what the database knows about the IP address. Use
+// LogValueDetail to log more:
+//
+//	slog.Info("request", "geo", record.LogValueDetail(geoip2.LogVerbose))
+type LogDetail int
+
+const (
+	// LogCompact includes the country, the ISO 3166-2 code of the most
+	// specific subdivision, the English city name, the number and
+	// organization of the autonomous system, the connection and user types,
+	// and the anonymizer flags that are set. It is used by the LogValue
+	// methods.
+	LogCompact LogDetail = iota
+	// LogVerbose adds the continent, the registered and represented
+	// countries, all subdivisions, the postal code, the location, the
+	// remaining network traits and the Enterprise confidence values.
+	LogVerbose
+)
+
+// LogValue returns the LogCompact group of c. It implements slog.LogValuer.
+func (c *City) LogValue() slog.Value {
+	return c.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of c at detail.
+func (c *City) LogValueDetail(detail LogDetail) slog.Value {
+	if c == nil {
+		return slog.GroupValue()
+	}
+	var a logAttrs
+	a.string("country", c.Country.IsoCode)
+	if detail < LogVerbose {
+		a.string("subdivision", lastString(c.SubdivisionISOCodes()))
+	} else {
+		a.strings("subdivisions", c.SubdivisionISOCodes())
+	}
+	a.string("city", c.City.Names["en"])
+	if detail >= LogVerbose {
+		a.string("postal", c.Postal.Code)
+		a.string("continent", c.Continent.Code)
+		a.bool("eu", c.Country.IsInEuropeanUnion)
+		a.string("registered_country", c.RegisteredCountry.IsoCode)
+		a.string("represented_country", c.RepresentedCountry.IsoCode)
+		a.string("represented_country_type", c.RepresentedCountry.Type)
+		a.location(&c.Location)
+		a.bool("anycast", c.Traits.IsAnycast)
+		a.bool("satellite_provider", c.Traits.IsSatelliteProvider)
+	}
+	a.anonymizer(logFlag{RiskAnonymousProxy, c.Traits.IsAnonymousProxy})
+	return a.value()
+}
+
+// LogValue returns the LogCompact group of c. It implements slog.LogValuer.
+func (c *Country) LogValue() slog.Value {
+	return c.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of c at detail.
+func (c *Country) LogValueDetail(detail LogDetail) slog.Value {
+	if c == nil {
+		return slog.GroupValue()
+	}
+	var a logAttrs
+	a.string("country", c.Country.IsoCode)
+	if detail >= LogVerbose {
+		a.string("continent", c.Continent.Code)
+		a.bool("eu", c.Country.IsInEuropeanUnion)
+		a.string("registered_country", c.RegisteredCountry.IsoCode)
+		a.string("represented_country", c.RepresentedCountry.IsoCode)
+		a.string("represented_country_type", c.RepresentedCountry.Type)
+		a.bool("anycast", c.Traits.IsAnycast)
+		a.bool("satellite_provider", c.Traits.IsSatelliteProvider)
+	}
+	a.anonymizer(logFlag{RiskAnonymousProxy, c.Traits.IsAnonymousProxy})
+	return a.value()
+}
+
+// LogValue returns the LogCompact group of e. It implements slog.LogValuer.
+func (e *Enterprise) LogValue() slog.Value {
+	return e.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of e at detail.
+func (e *Enterprise) LogValueDetail(detail LogDetail) slog.Value {
+	if e == nil {
+		return slog.GroupValue()
+	}
+	verbose := detail >= LogVerbose
+	traits := &e.Traits
+	var a logAttrs
+	a.string("country", e.Country.IsoCode)
+	if verbose {
+		a.uint("country_confidence", uint(e.Country.Confidence))
+		a.strings("subdivisions", e.SubdivisionISOCodes())
+		a.uint("subdivision_confidence", uint(e.MostSpecificSubdivision().Confidence))
+	} else {
+		a.string("subdivision", lastString(e.SubdivisionISOCodes()))
+	}
+	a.string("city", e.City.Names["en"])
+	if verbose {
+		a.uint("city_confidence", uint(e.City.Confidence))
+		a.string("postal", e.Postal.Code)
+		a.uint("postal_confidence", uint(e.Postal.Confidence))
+		a.string("continent", e.Continent.Code)
+		a.bool("eu", e.Country.IsInEuropeanUnion)
+		a.string("registered_country", e.RegisteredCountry.IsoCode)
+		a.string("represented_country", e.RepresentedCountry.IsoCode)
+		a.string("represented_country_type", e.RepresentedCountry.Type)
+		a.location(&e.Location)
+	}
+	a.asn(traits.AutonomousSystemNumber, traits.AutonomousSystemOrganization)
+	if verbose {
+		a.string("isp", traits.ISP)
+		a.string("organization", traits.Organization)
+		a.string("domain", traits.Domain)
+		a.string("mobile_country_code", traits.MobileCountryCode)
+		a.string("mobile_network_code", traits.MobileNetworkCode)
+	}
+	a.string("connection_type", traits.ConnectionType)
+	a.string("user_type", traits.UserType)
+	if verbose {
+		a.float("static_ip_score", traits.StaticIPScore)
+		a.bool("anycast", traits.IsAnycast)
+		a.bool("satellite_provider", traits.IsSatelliteProvider)
+		a.bool("legitimate_proxy", traits.IsLegitimateProxy)
+	}
+	a.anonymizer(logFlag{RiskAnonymousProxy, traits.IsAnonymousProxy})
+	return a.value()
+}
+
+// LogValue returns the LogCompact group of a. It implements slog.LogValuer.
+func (a *ASN) LogValue() slog.Value {
+	return a.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of a at detail. ASN records have no
+// additional data at LogVerbose.
+func (a *ASN) LogValueDetail(LogDetail) slog.Value {
+	if a == nil {
+		return slog.GroupValue()
+	}
+	var attrs logAttrs
+	attrs.asn(a.AutonomousSystemNumber, a.AutonomousSystemOrganization)
+	return attrs.value()
+}
+
+// LogValue returns the LogCompact group of i. It implements slog.LogValuer.
+func (i *ISP) LogValue() slog.Value {
+	return i.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of i at detail.
+func (i *ISP) LogValueDetail(detail LogDetail) slog.Value {
+	if i == nil {
+		return slog.GroupValue()
+	}
+	var a logAttrs
+	a.asn(i.AutonomousSystemNumber, i.AutonomousSystemOrganization)
+	a.string("isp", i.ISP)
+	if detail >= LogVerbose {
+		a.string("organization", i.Organization)
+		a.string("mobile_country_code", i.MobileCountryCode)
+		a.string("mobile_network_code", i.MobileNetworkCode)
+	}
+	return a.value()
+}
+
+// LogValue returns the LogCompact group of a. It implements slog.LogValuer.
+func (a *AnonymousIP) LogValue() slog.Value {
+	return a.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of a at detail. AnonymousIP records have
+// no additional data at LogVerbose.
+func (a *AnonymousIP) LogValueDetail(LogDetail) slog.Value {
+	if a == nil {
+		return slog.GroupValue()
+	}
+	var attrs logAttrs
+	attrs.anonymizer(
+		logFlag{RiskAnonymous, a.IsAnonymous},
+		logFlag{RiskAnonymousVPN, a.IsAnonymousVPN},
+		logFlag{RiskHostingProvider, a.IsHostingProvider},
+		logFlag{RiskPublicProxy, a.IsPublicProxy},
+		logFlag{RiskResidentialProxy, a.IsResidentialProxy},
+		logFlag{RiskTorExitNode, a.IsTorExitNode},
+	)
+	return attrs.value()
+}
+
+// LogValue returns the LogCompact group of c. It implements slog.LogValuer.
+func (c *ConnectionType) LogValue() slog.Value {
+	return c.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of c at detail. ConnectionType records
+// have no additional data at LogVerbose.
+func (c *ConnectionType) LogValueDetail(LogDetail) slog.Value {
+	if c == nil {
+		return slog.GroupValue()
+	}
+	var a logAttrs
+	a.string("connection_type", c.ConnectionType)
+	return a.value()
+}
+
+// LogValue returns the LogCompact group of d. It implements slog.LogValuer.
+func (d *Domain) LogValue() slog.Value {
+	return d.LogValueDetail(LogCompact)
+}
+
+// LogValueDetail returns the group of d at detail. Domain records have no
+// additional data at LogVerbose.
+func (d *Domain) LogValueDetail(LogDetail) slog.Value {
+	if d == nil {
+		return slog.GroupValue()
+	}
+	var a logAttrs
+	a.string("domain", d.Domain)
+	return a.value()
+}
+
+// logAttrs collects the attributes of a record group, skipping zero values.
+type logAttrs []slog.Attr
+
+func (a *logAttrs) value() slog.Value {
+	return slog.GroupValue(*a...)
+}
+
+func (a *logAttrs) string(key, value string) {
+	if value != "" {
+		*a = append(*a, slog.String(key, value))
+	}
+}
+
+func (a *logAttrs) strings(key string, values []string) {
+	if len(values) > 0 {
+		*a = append(*a, slog.Any(key, values))
+	}
+}
+
+func (a *logAttrs) uint(key string, value uint) {
+	if value != 0 {
+		*a = append(*a, slog.Uint64(key, uint64(value)))
+	}
+}
+
+func (a *logAttrs) float(key string, value float64) {
+	if value != 0 {
+		*a = append(*a, slog.Float64(key, value))
+	}
+}
+
+func (a *logAttrs) bool(key string, value bool) {
+	if value {
+		*a = append(*a, slog.Bool(key, true))
+	}
+}
+
+// asn adds the number and organization of the autonomous system as "asn"
+// and "as_org".
+func (a *logAttrs) asn(number uint, organization string) {
+	a.uint("asn", number)
+	a.string("as_org", organization)
+}
+
+// location adds the location unless it has no coordinates.
+func (a *logAttrs) location(l *Location) {
+	if l.Latitude != 0 || l.Longitude != 0 {
+		*a = append(*a,
+			slog.Float64("latitude", l.Latitude),
+			slog.Float64("longitude", l.Longitude),
+		)
+	}
+	a.uint("accuracy_radius", uint(l.AccuracyRadius))
+	a.string("time_zone", l.TimeZone)
+	a.uint("metro_code", l.MetroCode)
+}
+
+type logFlag struct {
+	signal RiskSignal
+	set    bool
+}
+
+// anonymizer adds the signals of the flags that are set as "anonymizer".
+func (a *logAttrs) anonymizer(flags ...logFlag) {
+	var signals []string
+	for _, flag := range flags {
+		if flag.set {
+			signals = append(signals, string(flag.signal))
+		}
+	}
+	a.strings("anonymizer", signals)
+}
+
+func lastString(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
@@ -0,0 +1,118 @@
+package geoip2
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLine returns the attributes logged for value under "geo" by a text
+// handler.
+func logLine(t *testing.T, value any) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key != "geo" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("lookup", "geo", value)
+	return strings.TrimSpace(buf.String())
+}
+
+func TestCityLogValue(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.City(net.ParseIP("2.125.160.216"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "geo.country=GB geo.subdivision=GB-WBK geo.city=Boxford", logLine(t, record))
+	assert.Equal(t,
+		`geo.country=GB geo.subdivisions="[GB-ENG GB-WBK]" geo.city=Boxford geo.postal=OX1 `+
+			"geo.continent=EU geo.registered_country=FR geo.latitude=51.75 geo.longitude=-1.25 "+
+			"geo.accuracy_radius=100 geo.time_zone=Europe/London",
+		logLine(t, record.LogValueDetail(LogVerbose)),
+	)
+
+	record, err = reader.City(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Empty(t, logLine(t, record))
+
+	var missing *City
+	assert.Empty(t, logLine(t, missing))
+}
+
+func TestCountryLogValue(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Country(net.ParseIP("89.160.20.112"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "geo.country=SE", logLine(t, record))
+	assert.Equal(t,
+		"geo.country=SE geo.continent=EU geo.eu=true geo.registered_country=DE",
+		logLine(t, record.LogValueDetail(LogVerbose)),
+	)
+}
+
+func TestEnterpriseLogValue(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Enterprise-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Enterprise(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Equal(t, "geo.country=GB geo.subdivision=GB-ENG geo.city=London", logLine(t, record))
+	assert.Contains(t, logLine(t, record.LogValueDetail(LogVerbose)),
+		"geo.country=GB geo.country_confidence=99 geo.subdivisions=[GB-ENG] geo.subdivision_confidence=80 "+
+			"geo.city=London geo.city_confidence=50",
+	)
+
+	record, err = reader.Enterprise(net.ParseIP("74.209.24.0"))
+	require.NoError(t, err)
+	assert.Contains(t, logLine(t, record), "geo.connection_type=Cable/DSL geo.user_type=residential")
+}
+
+func TestNetworkLogValues(t *testing.T) {
+	asnReader, err := Open("test-data/test-data/GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer asnReader.Close()
+
+	asn, err := asnReader.ASN(net.ParseIP("1.128.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, `geo.asn=1221 geo.as_org="Telstra Pty Ltd"`, logLine(t, asn))
+
+	anonymousReader, err := Open("test-data/test-data/GeoIP2-Anonymous-IP-Test.mmdb")
+	require.NoError(t, err)
+	defer anonymousReader.Close()
+
+	anonymous, err := anonymousReader.AnonymousIP(net.ParseIP("81.2.69.0"))
+	require.NoError(t, err)
+	assert.Equal(t,
+		`geo.anonymizer="[anonymous anonymous_vpn hosting_provider public_proxy residential_proxy tor_exit_node]"`,
+		logLine(t, anonymous),
+	)
+
+	assert.Equal(t,
+		"geo.connection_type=Cellular",
+		logLine(t, &ConnectionType{ConnectionType: string(ConnectionCellular)}),
+	)
+	assert.Equal(t, "geo.domain=example.com", logLine(t, &Domain{Domain: "example.com"}))
+	assert.Equal(t,
+		`geo.asn=1 geo.as_org=org geo.isp="Some ISP"`,
+		logLine(t, &ISP{AutonomousSystemNumber: 1, AutonomousSystemOrganization: "org", ISP: "Some ISP"}),
+	)
+}
@@ -0,0 +1,306 @@
+package geoip2
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"slices"
+)
+
+// MergePolicy determines which source a Merger takes each section of a
+// record from when more than one source has data for it.
+type MergePolicy int
+
+const (
+	// PreferPriority takes every section of the record from the
+	// highest-priority source that has it. Sources are prioritized in the
+	// order they were given to NewMerger unless MergeConfig.CountryPriority
+	// overrides that order for the country of the IP address.
+	PreferPriority MergePolicy = iota
+	// PreferConfidence takes the city, country, postal and subdivisions
+	// sections from the source reporting the highest Confidence for them.
+	// For subdivisions, the confidence of the most specific subdivision is
+	// used. Sources without confidence values, such as City databases, are
+	// treated as having a confidence of zero. Other sections and ties are
+	// resolved as with PreferPriority.
+	PreferConfidence
+	// PreferAccuracy takes the location, city, postal and subdivisions
+	// sections from the source with the smallest Location.AccuracyRadius so
+	// that they stay consistent with each other. A missing accuracy radius
+	// is treated as the least accurate. Other sections and ties are
+	// resolved as with PreferPriority.
+	PreferAccuracy
+)
+
+// MergeSource is a named Reader consulted by a Merger. The name is used in
+// the Provenance of merged records.
+type MergeSource struct {
+	Reader *Reader
+	Name   string
+}
+
+// MergeConfig configures a Merger.
+type MergeConfig struct {
+	// CountryPriority maps ISO 3166-1 country codes to source names in the
+	// order they should be preferred for IP addresses in that country.
+	// Sources not listed keep their original relative order after the
+	// listed ones. The country is taken from the first source, in the order
+	// given to NewMerger, that has country data for the IP address.
+	CountryPriority map[string][]string
+	// Policy is the merge policy to apply.
+	Policy MergePolicy
+}
+
+// Provenance maps the sections of a merged record, keyed by their database
+// names (e.g., "city" or "location"), and its traits, keyed by their dotted
+// database paths (e.g., "traits.isp"), to the name of the source that
+// provided them. Sections and traits that no source had are absent.
+type Provenance map[string]string
+
+// Merger combines the City or Enterprise records of several databases for
+// the same IP address, for instance when licensing data from more than one
+// vendor. Each section of the record, such as the city, the country or the
+// location, is taken as a whole from the source chosen by the configured
+// MergePolicy among those that have it, so that, e.g., the names, GeoNameID
+// and ISO code of a country always come from the same source. A section
+// missing from the preferred source is filled in from another one. The
+// traits are independent of each other and each of them is chosen
+// separately. Zero values, including false, are treated as missing.
+//
+// Sources whose database type does not support City lookups are ignored.
+// The Merger does not take ownership of the Readers; the caller remains
+// responsible for closing them.
+type Merger struct {
+	config  MergeConfig
+	sources []MergeSource
+}
+
+// NewMerger returns a Merger that combines the records of sources, highest
+// priority first, according to config.
+func NewMerger(config MergeConfig, sources ...MergeSource) *Merger {
+	return &Merger{config: config, sources: sources}
+}
+
+// City takes an IP address as a net.IP struct and returns a City struct
+// combined from the sources, its Provenance and/or an error.
+func (m *Merger) City(ipAddress net.IP) (*City, Provenance, error) {
+	var city City
+	prov, err := m.merge("City", ipAddress, func() any { return &City{} }, &city)
+	return &city, prov, err
+}
+
+// Enterprise takes an IP address as a net.IP struct and returns an
+// Enterprise struct combined from the sources, its Provenance and/or an
+// error. City and Country databases may be used as sources; the
+// Enterprise-only fields of their records are empty.
+func (m *Merger) Enterprise(ipAddress net.IP) (*Enterprise, Provenance, error) {
+	var enterprise Enterprise
+	prov, err := m.merge(
+		"Enterprise",
+		ipAddress,
+		func() any { return &Enterprise{} },
+		&enterprise,
+	)
+	return &enterprise, prov, err
+}
+
+// mergeSignals holds the values that the merge policies compare. It is
+// decoded separately from the record so that confidence values are
+// available even when merging into a City.
+type mergeSignals struct {
+	City struct {
+		Confidence uint8 `maxminddb:"confidence"`
+	} `maxminddb:"city"`
+	Country struct {
+		IsoCode    string `maxminddb:"iso_code"`
+		Confidence uint8  `maxminddb:"confidence"`
+	} `maxminddb:"country"`
+	Postal struct {
+		Confidence uint8 `maxminddb:"confidence"`
+	} `maxminddb:"postal"`
+	Subdivisions []struct {
+		Confidence uint8 `maxminddb:"confidence"`
+	} `maxminddb:"subdivisions"`
+	Location struct {
+		AccuracyRadius uint16 `maxminddb:"accuracy_radius"`
+	} `maxminddb:"location"`
+}
+
+type mergeCandidate struct {
+	record  reflect.Value
+	name    string
+	signals mergeSignals
+}
+
+func (m *Merger) merge(
+	method string,
+	ipAddress net.IP,
+	newRecord func() any,
+	result any,
+) (Provenance, error) {
+	var candidates []mergeCandidate
+	supported := false
+	for _, source := range m.sources {
+		if isCity&source.Reader.databaseType == 0 {
+			continue
+		}
+		supported = true
+
+		record := newRecord()
+		c := mergeCandidate{name: source.Name, record: reflect.ValueOf(record).Elem()}
+		found, err := source.Reader.observedLookup(
+			context.Background(),
+			"Merger."+method,
+			ipAddress,
+			record,
+			&c.signals,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			candidates = append(candidates, c)
+		}
+	}
+	if !supported {
+		return nil, InvalidMethodError{method, "merger"}
+	}
+
+	return m.combine(candidates, result), nil
+}
+
+// combine sets each section of result, which must be a pointer to a struct
+// of the same type as the candidate records, from the candidate chosen by
+// the merge policy. The traits are independent of each other, so each of
+// them is chosen separately.
+func (m *Merger) combine(candidates []mergeCandidate, result any) Provenance {
+	candidates = m.prioritize(candidates)
+
+	prov := Provenance{}
+	out := reflect.ValueOf(result).Elem()
+	for i := range out.NumField() {
+		section := out.Type().Field(i).Tag.Get("maxminddb")
+		if section != "traits" {
+			m.combineField(candidates, out.Field(i), section, section, prov, func(c mergeCandidate) reflect.Value {
+				return c.record.Field(i)
+			})
+			continue
+		}
+		traits := out.Field(i)
+		for j := range traits.NumField() {
+			path := section + "." + traits.Type().Field(j).Tag.Get("maxminddb")
+			m.combineField(candidates, traits.Field(j), section, path, prov, func(c mergeCandidate) reflect.Value {
+				return c.record.Field(i).Field(j)
+			})
+		}
+	}
+	return prov
+}
+
+// combineField sets out, the part of the record at path in section, from
+// the candidate chosen by the merge policy among those whose value, as
+// returned by value, is not zero.
+func (m *Merger) combineField(
+	candidates []mergeCandidate,
+	out reflect.Value,
+	section, path string,
+	prov Provenance,
+	value func(c mergeCandidate) reflect.Value,
+) {
+	best := chooseCandidate(m.config.Policy, section, candidates, func(i int) bool {
+		return !value(candidates[i]).IsZero()
+	})
+	if best < 0 {
+		return
+	}
+	out.Set(value(candidates[best]))
+	prov[path] = candidates[best].name
+}
+
+// prioritize reorders the candidates according to the country priority
+// configured for the country of the IP address, if any.
+func (m *Merger) prioritize(candidates []mergeCandidate) []mergeCandidate {
+	var country string
+	for _, c := range candidates {
+		if c.signals.Country.IsoCode != "" {
+			country = c.signals.Country.IsoCode
+			break
+		}
+	}
+	order, ok := m.config.CountryPriority[country]
+	if !ok {
+		return candidates
+	}
+
+	rank := func(c mergeCandidate) int {
+		if i := slices.Index(order, c.name); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	sorted := slices.Clone(candidates)
+	slices.SortStableFunc(sorted, func(a, b mergeCandidate) int {
+		return rank(a) - rank(b)
+	})
+	return sorted
+}
+
+// chooseCandidate returns the index of the candidate that should provide
+// section, the top-level part of the record, or one of its fields, or -1 if
+// none of them has data for it according to present. The candidates must be
+// in priority order.
+func chooseCandidate(
+	policy MergePolicy,
+	section string,
+	candidates []mergeCandidate,
+	present func(i int) bool,
+) int {
+	var score func(c mergeCandidate) int
+	switch policy {
+	case PreferConfidence:
+		switch section {
+		case "city":
+			score = func(c mergeCandidate) int { return int(c.signals.City.Confidence) }
+		case "country":
+			score = func(c mergeCandidate) int { return int(c.signals.Country.Confidence) }
+		case "postal":
+			score = func(c mergeCandidate) int { return int(c.signals.Postal.Confidence) }
+		case "subdivisions":
+			score = func(c mergeCandidate) int {
+				if len(c.signals.Subdivisions) == 0 {
+					return 0
+				}
+				return int(c.signals.Subdivisions[len(c.signals.Subdivisions)-1].Confidence)
+			}
+		}
+	case PreferAccuracy:
+		switch section {
+		case "location", "city", "postal", "subdivisions":
+			score = func(c mergeCandidate) int {
+				radius := int(c.signals.Location.AccuracyRadius)
+				if radius == 0 {
+					return -(1 << 16)
+				}
+				return -radius
+			}
+		}
+	}
+
+	best := -1
+	for i, c := range candidates {
+		if !present(i) {
+			continue
+		}
+		if best < 0 {
+			best = i
+			if score == nil {
+				break
+			}
+			continue
+		}
+		if score(c) > score(candidates[best]) {
+			best = i
+		}
+	}
+	return best
+}
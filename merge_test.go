@@ -0,0 +1,189 @@
+package geoip2
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCandidate(name string, record *Enterprise) mergeCandidate {
+	c := mergeCandidate{name: name, record: reflect.ValueOf(record).Elem()}
+	c.signals.City.Confidence = record.City.Confidence
+	c.signals.Country.IsoCode = record.Country.IsoCode
+	c.signals.Country.Confidence = record.Country.Confidence
+	c.signals.Postal.Confidence = record.Postal.Confidence
+	c.signals.Location.AccuracyRadius = record.Location.AccuracyRadius
+	return c
+}
+
+func testMergeCandidates() []mergeCandidate {
+	var a Enterprise
+	a.Country.IsoCode = "DE"
+	a.Country.Confidence = 90
+	a.City.Names = map[string]string{"en": "Berlin"}
+	a.City.Confidence = 20
+	a.Location.AccuracyRadius = 200
+	a.Location.Longitude = 13.4
+	a.Traits.ISP = "Vendor A ISP"
+
+	var b Enterprise
+	b.Country.IsoCode = "DE"
+	b.Country.Confidence = 80
+	b.City.Names = map[string]string{"en": "Potsdam"}
+	b.City.Confidence = 60
+	b.Postal.Code = "14467"
+	b.Location.AccuracyRadius = 20
+	b.Location.Latitude = 52.4
+	b.Location.Longitude = 13.06
+	b.Traits.Domain = "vendor-b.example"
+
+	return []mergeCandidate{newTestCandidate("a", &a), newTestCandidate("b", &b)}
+}
+
+func TestMergerPolicies(t *testing.T) {
+	// provenance returns the Provenance of the test candidates when the
+	// city, country and location sections come from the given sources.
+	provenance := func(city, country, location string) Provenance {
+		return Provenance{
+			"city":          city,
+			"country":       country,
+			"location":      location,
+			"postal":        "b",
+			"traits.isp":    "a",
+			"traits.domain": "b",
+		}
+	}
+	tests := []struct {
+		config   MergeConfig
+		expected Provenance
+		name     string
+		city     string
+	}{
+		{
+			name:     "priority",
+			config:   MergeConfig{Policy: PreferPriority},
+			city:     "Berlin",
+			expected: provenance("a", "a", "a"),
+		},
+		{
+			name:     "confidence",
+			config:   MergeConfig{Policy: PreferConfidence},
+			city:     "Potsdam",
+			expected: provenance("b", "a", "a"),
+		},
+		{
+			name:     "accuracy",
+			config:   MergeConfig{Policy: PreferAccuracy},
+			city:     "Potsdam",
+			expected: provenance("b", "a", "b"),
+		},
+		{
+			name: "country priority",
+			config: MergeConfig{
+				Policy:          PreferPriority,
+				CountryPriority: map[string][]string{"DE": {"b"}},
+			},
+			city:     "Potsdam",
+			expected: provenance("b", "b", "b"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMerger(test.config)
+
+			var record Enterprise
+			prov := m.combine(testMergeCandidates(), &record)
+
+			assert.Equal(t, test.expected, prov)
+			assert.Equal(t, test.city, record.City.Names["en"])
+			assert.Equal(t, "Vendor A ISP", record.Traits.ISP)
+			assert.Equal(t, "vendor-b.example", record.Traits.Domain)
+			if test.expected["location"] == "a" {
+				assert.Zero(t, record.Location.Latitude)
+				assert.InDelta(t, 13.4, record.Location.Longitude, 0)
+			}
+		})
+	}
+}
+
+func TestMergerCity(t *testing.T) {
+	cityReader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer cityReader.Close()
+
+	enterpriseReader, err := Open("test-data/test-data/GeoIP2-Enterprise-Test.mmdb")
+	require.NoError(t, err)
+	defer enterpriseReader.Close()
+
+	m := NewMerger(
+		MergeConfig{Policy: PreferPriority},
+		MergeSource{Name: "city", Reader: cityReader},
+		MergeSource{Name: "enterprise", Reader: enterpriseReader},
+	)
+
+	record, prov, err := m.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Equal(t, "London", record.City.Names["en"])
+	assert.Equal(t, "city", prov["city"])
+
+	enterprise, prov, err := m.Enterprise(net.ParseIP("74.209.24.0"))
+	require.NoError(t, err)
+	assert.Equal(t, uint(14671), enterprise.Traits.AutonomousSystemNumber)
+	assert.Equal(t, "enterprise", prov["traits.autonomous_system_number"])
+	assert.NotContains(t, prov, "traits")
+
+	_, prov, err = m.City(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Empty(t, prov)
+}
+
+func TestMergerKeepsSectionsTogether(t *testing.T) {
+	// A source without GeoNames IDs, whose country and city disagree with
+	// the other source.
+	a := testDatabase(t, "GeoIP2-City", map[string]map[string]any{
+		"81.2.69.128/26": {
+			"city":    map[string]any{"names": map[string]any{"en": "Potsdam"}},
+			"country": map[string]any{"iso_code": "AT"},
+		},
+	})
+	b := testDatabase(t, "GeoIP2-City", map[string]map[string]any{
+		"81.2.69.128/26": {
+			"city": map[string]any{
+				"geoname_id": 2950159,
+				"names":      map[string]any{"en": "Berlin"},
+			},
+			"country": map[string]any{
+				"geoname_id": 2921044,
+				"iso_code":   "DE",
+				"names":      map[string]any{"en": "Germany"},
+			},
+			"postal": map[string]any{"code": "10115"},
+		},
+	})
+	m := NewMerger(MergeConfig{}, MergeSource{Name: "a", Reader: a}, MergeSource{Name: "b", Reader: b})
+
+	record, prov, err := m.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Equal(t, "AT", record.Country.IsoCode)
+	assert.Empty(t, record.Country.Names)
+	assert.Zero(t, record.Country.GeoNameID)
+	assert.Equal(t, map[string]string{"en": "Potsdam"}, record.City.Names)
+	assert.Zero(t, record.City.GeoNameID)
+	assert.Equal(t, "10115", record.Postal.Code)
+	assert.Equal(t, Provenance{"city": "a", "country": "a", "postal": "b"}, prov)
+}
+
+func TestMergerInvalidMethod(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	m := NewMerger(MergeConfig{}, MergeSource{Name: "asn", Reader: reader})
+
+	_, _, err = m.City(net.ParseIP("1.128.0.0"))
+	assert.Equal(t, InvalidMethodError{"City", "merger"}, err)
+}
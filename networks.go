@@ -0,0 +1,50 @@
+package geoip2
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Networks is an iterator over the networks in a database. It is created
+// using the Networks and NetworksWithin methods on Reader.
+//
+// Networks are returned in ascending order. In IPv6 databases, the aliases
+// of the IPv4 subtree (e.g., ::ffff:0:0/96 and 2002::/16) are skipped and
+// IPv4 networks are returned once, as IPv4 networks.
+type Networks struct {
+	networks *maxminddb.Networks
+}
+
+// Networks returns an iterator over all of the networks in the database.
+func (r *Reader) Networks() *Networks {
+	return &Networks{r.mmdbReader.Networks(maxminddb.SkipAliasedNetworks)}
+}
+
+// NetworksWithin returns an iterator over the networks in the database that
+// are contained in network. If network is itself contained in a network in
+// the database, the iterator returns exactly one network, the containing
+// network.
+func (r *Reader) NetworksWithin(network *net.IPNet) *Networks {
+	return &Networks{
+		r.mmdbReader.NetworksWithin(network, maxminddb.SkipAliasedNetworks),
+	}
+}
+
+// Next prepares the next network for reading with the Network method. It
+// returns false when there are no more networks or when an error occurred,
+// in which case Err returns the error.
+func (n *Networks) Next() bool {
+	return n.networks.Next()
+}
+
+// Network returns the current network and stores its record in the value
+// pointed to by result, e.g., a *City when iterating over a City database.
+func (n *Networks) Network(result any) (*net.IPNet, error) {
+	return n.networks.Network(result)
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (n *Networks) Err() error {
+	return n.networks.Err()
+}
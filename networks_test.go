@@ -0,0 +1,68 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworks(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	ip := net.ParseIP("81.2.69.160")
+	var (
+		count    int
+		previous *net.IPNet
+		london   *City
+	)
+	networks := reader.Networks()
+	for networks.Next() {
+		var record City
+		network, err := networks.Network(&record)
+		require.NoError(t, err)
+
+		if network.IP.To4() != nil {
+			assert.Len(t, network.IP, net.IPv4len, "IPv4 network %s", network)
+		}
+		if previous != nil {
+			assert.False(t, previous.Contains(network.IP), "%s after %s", network, previous)
+		}
+		if network.Contains(ip) {
+			london = &record
+		}
+		previous = network
+		count++
+	}
+	require.NoError(t, networks.Err())
+
+	assert.Positive(t, count)
+	require.NotNil(t, london)
+	assert.Equal(t, "London", london.City.Names["en"])
+}
+
+func TestNetworksWithin(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, within, err := net.ParseCIDR("81.2.69.0/24")
+	require.NoError(t, err)
+
+	var count int
+	networks := reader.NetworksWithin(within)
+	for networks.Next() {
+		var record City
+		network, err := networks.Network(&record)
+		require.NoError(t, err)
+
+		assert.True(t, within.Contains(network.IP), "%s in %s", network, within)
+		assert.Equal(t, "GB", record.Country.IsoCode)
+		count++
+	}
+	require.NoError(t, networks.Err())
+	assert.Positive(t, count)
+}
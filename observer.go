@@ -0,0 +1,123 @@
+package geoip2
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// LookupEvent describes a lookup performed by a Reader.
+type LookupEvent struct {
+	// Start is the time the lookup started.
+	Start time.Time
+	// Err is the error returned by the lookup, if any.
+	Err error
+	// Method is the name of the lookup method, e.g., "City" or
+	// "LookupNetwork".
+	Method string
+	// DatabaseType is the database type from the metadata of the Reader,
+	// e.g., "GeoIP2-City".
+	DatabaseType string
+	// Duration is the time the lookup took, including decoding the record.
+	Duration time.Duration
+	// Found reports whether the database had a record for the IP address.
+	Found bool
+}
+
+// Observer is notified of the lookups performed by a Reader, e.g., to
+// collect metrics or record tracing spans. ObserveLookup is called
+// synchronously after every lookup, so it must be fast and safe for
+// concurrent use. ctx is the context given to the Context variant of the
+// lookup method, e.g., Reader.CityContext, or context.Background().
+//
+// The lookups that an Overlay or a Merger performs in a Reader are reported
+// with the name of their method prefixed with "Overlay." or "Merger.", e.g.,
+// "Overlay.City". Iterating over Networks and querying a SpatialIndex do not
+// look up IP addresses and are not reported.
+type Observer interface {
+	ObserveLookup(ctx context.Context, event LookupEvent)
+}
+
+// MultiObserver returns an Observer that notifies each of observers in
+// turn. Nil observers are skipped.
+func MultiObserver(observers ...Observer) Observer {
+	var all multiObserver
+	for _, observer := range observers {
+		switch o := observer.(type) {
+		case nil:
+		case multiObserver:
+			all = append(all, o...)
+		default:
+			all = append(all, o)
+		}
+	}
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	default:
+		return all
+	}
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) ObserveLookup(ctx context.Context, event LookupEvent) {
+	for _, observer := range m {
+		observer.ObserveLookup(ctx, event)
+	}
+}
+
+// SetObserver sets the Observer notified of the lookups performed by r. A
+// nil observer disables notifications. Use MultiObserver to notify several
+// observers. SetObserver must not be called concurrently with lookups.
+func (r *Reader) SetObserver(observer Observer) {
+	r.observer = observer
+}
+
+// Observer returns the Observer notified of the lookups performed by r, or
+// nil if there is none.
+func (r *Reader) Observer() Observer {
+	return r.observer
+}
+
+// observedLookup performs a lookup using method, storing the record in each
+// of results, and notifies the observer of r, if any, with ctx. It reports
+// whether the IP address was found.
+func (r *Reader) observedLookup(
+	ctx context.Context,
+	method string,
+	ipAddress net.IP,
+	results ...any,
+) (bool, error) {
+	return r.observe(ctx, method, func() (bool, error) {
+		return r.lookup(ipAddress, results...)
+	})
+}
+
+// observe calls lookup, which performs a lookup using method and reports
+// whether the IP address was found, and notifies the observer of r, if any,
+// with ctx.
+func (r *Reader) observe(ctx context.Context, method string, lookup func() (bool, error)) (bool, error) {
+	if r.observer == nil {
+		return lookup()
+	}
+	start := time.Now()
+	found, err := lookup()
+	r.notify(ctx, method, start, found, err)
+	return found, err
+}
+
+// notify notifies the observer of r, with ctx, of a lookup using method
+// that started at start.
+func (r *Reader) notify(ctx context.Context, method string, start time.Time, found bool, err error) {
+	r.observer.ObserveLookup(ctx, LookupEvent{
+		Start:        start,
+		Err:          err,
+		Method:       method,
+		DatabaseType: r.mmdbReader.Metadata.DatabaseType,
+		Duration:     time.Since(start),
+		Found:        found,
+	})
+}
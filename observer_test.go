@@ -0,0 +1,145 @@
+package geoip2
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	contexts []context.Context
+	events   []LookupEvent
+}
+
+func (o *recordingObserver) ObserveLookup(ctx context.Context, event LookupEvent) {
+	o.contexts = append(o.contexts, ctx)
+	o.events = append(o.events, event)
+}
+
+func TestObserver(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	observer := &recordingObserver{}
+	reader.SetObserver(observer)
+
+	_, err = reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	_, err = reader.Country(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	var record map[string]any
+	_, _, err = reader.LookupNetwork(net.ParseIP("2001:218::"), &record)
+	require.NoError(t, err)
+	_, err = reader.City(nil)
+	require.Error(t, err)
+	// Methods the database does not support are not lookups.
+	_, err = reader.ASN(net.ParseIP("81.2.69.160"))
+	require.Error(t, err)
+
+	require.Len(t, observer.events, 4)
+	for i, event := range observer.events {
+		assert.Equal(t, "GeoIP2-City", event.DatabaseType)
+		assert.False(t, event.Start.IsZero())
+		assert.Equal(t, context.Background(), observer.contexts[i])
+	}
+
+	assert.Equal(t, "City", observer.events[0].Method)
+	assert.True(t, observer.events[0].Found)
+	assert.NoError(t, observer.events[0].Err)
+
+	assert.Equal(t, "Country", observer.events[1].Method)
+	assert.False(t, observer.events[1].Found)
+
+	assert.Equal(t, "LookupNetwork", observer.events[2].Method)
+	assert.True(t, observer.events[2].Found)
+
+	assert.Equal(t, "City", observer.events[3].Method)
+	assert.False(t, observer.events[3].Found)
+	assert.Error(t, observer.events[3].Err)
+
+	reader.SetObserver(nil)
+	_, err = reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Len(t, observer.events, 4)
+}
+
+func TestObserverOverlayAndMerger(t *testing.T) {
+	city, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer city.Close()
+	country, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer country.Close()
+
+	observer := &recordingObserver{}
+	city.SetObserver(observer)
+	country.SetObserver(observer)
+
+	ip := net.ParseIP("81.2.69.160")
+	overlay := NewOverlay(FirstFound, OverlayLayer{Reader: city, Name: "city"})
+	_, _, err = overlay.City(ip)
+	require.NoError(t, err)
+	merger := NewMerger(MergeConfig{}, MergeSource{Reader: city, Name: "city"})
+	_, _, err = merger.City(ip)
+	require.NoError(t, err)
+	_, _, err = NewOverlay(MergeFields, OverlayLayer{Reader: country, Name: "country"}).Country(ip)
+	require.NoError(t, err)
+
+	require.Len(t, observer.events, 3)
+	assert.Equal(t, "Overlay.City", observer.events[0].Method)
+	assert.True(t, observer.events[0].Found)
+	assert.Equal(t, "Merger.City", observer.events[1].Method)
+	assert.True(t, observer.events[1].Found)
+	assert.Equal(t, "Overlay.Country", observer.events[2].Method)
+	assert.Equal(t, "GeoIP2-Country", observer.events[2].DatabaseType)
+}
+
+type contextKey struct{}
+
+func TestObserverContext(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	observer := &recordingObserver{}
+	reader.SetObserver(observer)
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "request")
+	ip := net.ParseIP("81.2.69.160")
+	_, err = reader.CityContext(ctx, ip)
+	require.NoError(t, err)
+	_, err = reader.City(ip)
+	require.NoError(t, err)
+	var record map[string]any
+	_, _, err = reader.LookupNetworkContext(ctx, ip, &record)
+	require.NoError(t, err)
+	_, err = reader.ASNContext(ctx, ip)
+	require.Error(t, err)
+
+	require.Len(t, observer.contexts, 3)
+	assert.Equal(t, "request", observer.contexts[0].Value(contextKey{}))
+	assert.Nil(t, observer.contexts[1].Value(contextKey{}))
+	assert.Equal(t, "request", observer.contexts[2].Value(contextKey{}))
+	assert.Equal(t, "LookupNetwork", observer.events[2].Method)
+}
+
+func TestMultiObserver(t *testing.T) {
+	assert.Nil(t, MultiObserver())
+	assert.Nil(t, MultiObserver(nil, nil))
+
+	a, b, c := &recordingObserver{}, &recordingObserver{}, &recordingObserver{}
+	assert.Same(t, a, MultiObserver(nil, a))
+
+	observer := MultiObserver(MultiObserver(a, b), nil, c)
+	assert.Len(t, observer, 3)
+
+	observer.ObserveLookup(context.Background(), LookupEvent{Method: "City"})
+	for _, o := range []*recordingObserver{a, b, c} {
+		require.Len(t, o.events, 1)
+		assert.Equal(t, "City", o.events[0].Method)
+	}
+}
@@ -0,0 +1,233 @@
+package geoip2
+
+import (
+	"context"
+	"net"
+	"reflect"
+)
+
+// OverlayMode controls how an Overlay combines the records found in its
+// layers.
+type OverlayMode int
+
+const (
+	// FirstFound returns the record from the highest-priority layer that
+	// contains the IP address. Lower-priority layers are not consulted once a
+	// record has been found.
+	FirstFound OverlayMode = iota
+	// MergeFields combines the records from every layer that contains the IP
+	// address. Fields present in a higher-priority layer replace the same
+	// fields from lower-priority layers, while fields missing from it are
+	// taken from the layers below. Named places, i.e., the city, continent,
+	// country, registered country and represented country, are replaced as
+	// a whole, including their names, GeoNameID and ISO code, so that a
+	// layer setting only the English name of a city does not keep the names
+	// and GeoNameID of another city. Slices such as Subdivisions are also
+	// replaced as a whole.
+	MergeFields
+)
+
+// OverlayLayer is a named Reader consulted by an Overlay. The name is
+// reported in OverlayResult so that callers can tell which database
+// provided the data.
+type OverlayLayer struct {
+	Reader *Reader
+	Name   string
+}
+
+// OverlayResult reports which layers of an Overlay contained the looked-up
+// IP address.
+type OverlayResult struct {
+	// Sources holds the names of the layers that had a record for the IP
+	// address, in priority order. When using FirstFound, it contains at most
+	// one name.
+	Sources []string
+}
+
+// Found reports whether any layer had a record for the IP address.
+func (r OverlayResult) Found() bool {
+	return len(r.Sources) > 0
+}
+
+// Source returns the name of the highest-priority layer that had a record
+// for the IP address or an empty string if no layer had one.
+func (r OverlayResult) Source() string {
+	if len(r.Sources) == 0 {
+		return ""
+	}
+	return r.Sources[0]
+}
+
+// Overlay consults an ordered list of Readers on every lookup. This allows
+// a small database of local corrections, such as the locations of your own
+// networks, to take precedence over a vendor database. Layers are given in
+// priority order; the first layer has the highest priority.
+//
+// Layers whose database type does not support a lookup method are skipped
+// for that method. The Overlay does not take ownership of the Readers; the
+// caller remains responsible for closing them.
+type Overlay struct {
+	layers []OverlayLayer
+	mode   OverlayMode
+}
+
+// NewOverlay returns an Overlay that consults layers, highest priority
+// first, and combines their records according to mode.
+func NewOverlay(mode OverlayMode, layers ...OverlayLayer) *Overlay {
+	return &Overlay{layers: layers, mode: mode}
+}
+
+// Enterprise takes an IP address as a net.IP struct and returns an Enterprise
+// struct, the layers that provided it and/or an error.
+func (o *Overlay) Enterprise(ipAddress net.IP) (*Enterprise, OverlayResult, error) {
+	var enterprise Enterprise
+	res, err := o.lookup("Enterprise", isEnterprise, ipAddress, &enterprise)
+	return &enterprise, res, err
+}
+
+// City takes an IP address as a net.IP struct and returns a City struct, the
+// layers that provided it and/or an error.
+func (o *Overlay) City(ipAddress net.IP) (*City, OverlayResult, error) {
+	var city City
+	res, err := o.lookup("City", isCity, ipAddress, &city)
+	return &city, res, err
+}
+
+// Country takes an IP address as a net.IP struct and returns a Country
+// struct, the layers that provided it and/or an error.
+func (o *Overlay) Country(ipAddress net.IP) (*Country, OverlayResult, error) {
+	var country Country
+	res, err := o.lookup("Country", isCountry, ipAddress, &country)
+	return &country, res, err
+}
+
+// AnonymousIP takes an IP address as a net.IP struct and returns an
+// AnonymousIP struct, the layers that provided it and/or an error.
+func (o *Overlay) AnonymousIP(ipAddress net.IP) (*AnonymousIP, OverlayResult, error) {
+	var anonIP AnonymousIP
+	res, err := o.lookup("AnonymousIP", isAnonymousIP, ipAddress, &anonIP)
+	return &anonIP, res, err
+}
+
+// ASN takes an IP address as a net.IP struct and returns an ASN struct, the
+// layers that provided it and/or an error.
+func (o *Overlay) ASN(ipAddress net.IP) (*ASN, OverlayResult, error) {
+	var val ASN
+	res, err := o.lookup("ASN", isASN, ipAddress, &val)
+	return &val, res, err
+}
+
+// ConnectionType takes an IP address as a net.IP struct and returns a
+// ConnectionType struct, the layers that provided it and/or an error.
+func (o *Overlay) ConnectionType(ipAddress net.IP) (*ConnectionType, OverlayResult, error) {
+	var val ConnectionType
+	res, err := o.lookup("ConnectionType", isConnectionType, ipAddress, &val)
+	return &val, res, err
+}
+
+// Domain takes an IP address as a net.IP struct and returns a Domain struct,
+// the layers that provided it and/or an error.
+func (o *Overlay) Domain(ipAddress net.IP) (*Domain, OverlayResult, error) {
+	var val Domain
+	res, err := o.lookup("Domain", isDomain, ipAddress, &val)
+	return &val, res, err
+}
+
+// ISP takes an IP address as a net.IP struct and returns an ISP struct, the
+// layers that provided it and/or an error.
+func (o *Overlay) ISP(ipAddress net.IP) (*ISP, OverlayResult, error) {
+	var val ISP
+	res, err := o.lookup("ISP", isISP, ipAddress, &val)
+	return &val, res, err
+}
+
+func (o *Overlay) lookup(
+	method string,
+	dbType databaseType,
+	ipAddress net.IP,
+	result any,
+) (OverlayResult, error) {
+	var res OverlayResult
+	supported := false
+
+	if o.mode == FirstFound {
+		for _, layer := range o.layers {
+			if dbType&layer.Reader.databaseType == 0 {
+				continue
+			}
+			supported = true
+			found, err := layer.Reader.observedLookup(context.Background(), "Overlay."+method, ipAddress, result)
+			if err != nil {
+				return res, err
+			}
+			if found {
+				res.Sources = []string{layer.Name}
+				return res, nil
+			}
+		}
+	} else {
+		// Decoding into the same result from the lowest to the highest
+		// priority layer lets the decoder overwrite exactly those fields
+		// that are present in each higher-priority record. Named places
+		// are cleared first so that they are replaced as a whole.
+		for i := len(o.layers) - 1; i >= 0; i-- {
+			layer := o.layers[i]
+			if dbType&layer.Reader.databaseType == 0 {
+				continue
+			}
+			supported = true
+			found, err := layer.Reader.observe(context.Background(), "Overlay."+method, func() (bool, error) {
+				return overlayRecord(layer.Reader, ipAddress, result)
+			})
+			if err != nil {
+				return OverlayResult{}, err
+			}
+			if found {
+				res.Sources = append([]string{layer.Name}, res.Sources...)
+			}
+		}
+	}
+
+	if !supported {
+		return res, InvalidMethodError{method, "overlay"}
+	}
+	return res, nil
+}
+
+// overlayPlaces records which named places a record has.
+type overlayPlaces struct {
+	City               *struct{} `maxminddb:"city"`
+	Continent          *struct{} `maxminddb:"continent"`
+	Country            *struct{} `maxminddb:"country"`
+	RegisteredCountry  *struct{} `maxminddb:"registered_country"`
+	RepresentedCountry *struct{} `maxminddb:"represented_country"`
+}
+
+// overlayRecord decodes the record for ipAddress from reader over result,
+// which holds the records of lower-priority layers, after clearing the
+// named places that the record has. It reports whether reader had a record.
+func overlayRecord(reader *Reader, ipAddress net.IP, result any) (bool, error) {
+	offset, found, err := reader.lookupOffset(ipAddress)
+	if !found {
+		return false, err
+	}
+	var places overlayPlaces
+	if err := reader.mmdbReader.Decode(offset, &places); err != nil {
+		return true, err
+	}
+
+	present := reflect.ValueOf(places)
+	out := reflect.ValueOf(result).Elem()
+	for i := range present.NumField() {
+		if present.Field(i).IsNil() {
+			continue
+		}
+		key := present.Type().Field(i).Tag.Get("maxminddb")
+		for j := range out.NumField() {
+			if out.Type().Field(j).Tag.Get("maxminddb") == key {
+				out.Field(j).SetZero()
+			}
+		}
+	}
+	return true, reader.mmdbReader.Decode(offset, result)
+}
@@ -0,0 +1,110 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openOverlayLayers(t *testing.T) (override, vendor OverlayLayer) {
+	t.Helper()
+
+	countryReader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	t.Cleanup(func() { countryReader.Close() })
+
+	cityReader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	t.Cleanup(func() { cityReader.Close() })
+
+	return OverlayLayer{Name: "override", Reader: countryReader},
+		OverlayLayer{Name: "vendor", Reader: cityReader}
+}
+
+func TestOverlayFirstFound(t *testing.T) {
+	override, vendor := openOverlayLayers(t)
+	overlay := NewOverlay(FirstFound, override, vendor)
+
+	record, res, err := overlay.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"override"}, res.Sources)
+	assert.Equal(t, "override", res.Source())
+	assert.Equal(t, "GB", record.Country.IsoCode)
+	// The Country database has no city data and the vendor layer is not
+	// consulted once a record has been found.
+	assert.Empty(t, record.City.Names)
+}
+
+func TestOverlayMergeFields(t *testing.T) {
+	override, vendor := openOverlayLayers(t)
+	overlay := NewOverlay(MergeFields, override, vendor)
+
+	record, res, err := overlay.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"override", "vendor"}, res.Sources)
+	assert.Equal(t, "override", res.Source())
+	assert.Equal(t, "GB", record.Country.IsoCode)
+	assert.Equal(t, "London", record.City.Names["en"])
+	assert.Equal(t, "Europe/London", record.Location.TimeZone)
+}
+
+func TestOverlayMergeFieldsReplacesPlaces(t *testing.T) {
+	// An internal correction that only names the city of an office network.
+	override := testDatabase(t, "GeoIP2-City", map[string]map[string]any{
+		"81.2.69.128/26": {"city": map[string]any{"names": map[string]any{"en": "Office"}}},
+	})
+	_, vendor := openOverlayLayers(t)
+	overlay := NewOverlay(MergeFields, OverlayLayer{Name: "override", Reader: override}, vendor)
+
+	record, res, err := overlay.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"override", "vendor"}, res.Sources)
+	assert.Equal(t, map[string]string{"en": "Office"}, record.City.Names)
+	assert.Zero(t, record.City.GeoNameID)
+	// Places and fields that the override does not have come from the
+	// vendor.
+	assert.Equal(t, "GB", record.Country.IsoCode)
+	assert.Equal(t, "United Kingdom", record.Country.Names["en"])
+	assert.Equal(t, "Europe/London", record.Location.TimeZone)
+}
+
+func TestOverlayNotFound(t *testing.T) {
+	override, vendor := openOverlayLayers(t)
+
+	for _, mode := range []OverlayMode{FirstFound, MergeFields} {
+		overlay := NewOverlay(mode, override, vendor)
+
+		record, res, err := overlay.Country(net.ParseIP("10.0.0.1"))
+		require.NoError(t, err)
+
+		assert.False(t, res.Found())
+		assert.Empty(t, res.Source())
+		assert.Empty(t, record.Country.IsoCode)
+	}
+}
+
+func TestOverlaySkipsUnsupportedLayers(t *testing.T) {
+	asnReader, err := Open("test-data/test-data/GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer asnReader.Close()
+
+	_, vendor := openOverlayLayers(t)
+	overlay := NewOverlay(
+		FirstFound,
+		OverlayLayer{Name: "asn", Reader: asnReader},
+		vendor,
+	)
+
+	record, res, err := overlay.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Equal(t, "vendor", res.Source())
+	assert.Equal(t, "London", record.City.Names["en"])
+
+	_, _, err = overlay.Domain(net.ParseIP("81.2.69.160"))
+	assert.Equal(t, InvalidMethodError{"Domain", "overlay"}, err)
+}
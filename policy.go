@@ -0,0 +1,219 @@
+package geoip2
+
+import (
+	"slices"
+	"strings"
+)
+
+// PolicyAction is the action taken by a CountryPolicy.
+type PolicyAction int
+
+const (
+	// PolicyAllow allows the request.
+	PolicyAllow PolicyAction = iota
+	// PolicyBlock blocks the request.
+	PolicyBlock
+)
+
+func (a PolicyAction) String() string {
+	switch a {
+	case PolicyAllow:
+		return "allow"
+	case PolicyBlock:
+		return "block"
+	default:
+		return "unknown"
+	}
+}
+
+// PolicyScope is a set of the countries of a record that a CountryRule is
+// matched against.
+type PolicyScope int
+
+const (
+	// PolicyCountry is the country where the IP address is located,
+	// including its subdivisions.
+	PolicyCountry PolicyScope = 1 << iota
+	// PolicyRegisteredCountry is the country in which the network is
+	// registered.
+	PolicyRegisteredCountry
+	// PolicyRepresentedCountry is the country represented by the users of
+	// the network, e.g., the country of a military base.
+	PolicyRepresentedCountry
+
+	policyAllScopes = PolicyCountry | PolicyRegisteredCountry | PolicyRepresentedCountry
+)
+
+func (s PolicyScope) String() string {
+	var names []string
+	if s&PolicyCountry != 0 {
+		names = append(names, "country")
+	}
+	if s&PolicyRegisteredCountry != 0 {
+		names = append(names, "registered_country")
+	}
+	if s&PolicyRepresentedCountry != 0 {
+		names = append(names, "represented_country")
+	}
+	return strings.Join(names, "|")
+}
+
+// CountryRule is a rule of a CountryPolicy. It matches a record if one of
+// the countries in its scope is listed in Countries or, for PolicyCountry,
+// if the full ISO 3166-2 code of one of the subdivisions, e.g., "UA-43", is
+// listed in Subdivisions. Codes are case-insensitive.
+type CountryRule struct {
+	// Name identifies the rule in decisions, e.g., for audit logs.
+	Name         string
+	Countries    []CountryCode
+	Subdivisions []string
+	// Scope selects the countries of the record the rule is matched
+	// against. If zero, all of them are.
+	Scope  PolicyScope
+	Action PolicyAction
+}
+
+// CountryPolicyConfig configures a CountryPolicy.
+type CountryPolicyConfig struct {
+	// Rules are evaluated in order and the first one that matches decides.
+	// To block a country except for some of its subdivisions, list allow
+	// rules for the subdivisions before the block rule for the country.
+	Rules []CountryRule
+	// DefaultAction is taken when no rule matches, including when the
+	// record has no country. Use PolicyBlock with allow rules for an allow
+	// list.
+	DefaultAction PolicyAction
+}
+
+// CountryDecision is the result of evaluating a CountryPolicy against a
+// record.
+type CountryDecision struct {
+	// Rule is a copy of the rule that matched, with its codes in upper
+	// case, or nil if the default action was taken.
+	Rule *CountryRule
+	// Value is the country or subdivision code that matched the rule.
+	Value string
+	// Matched is the country of the record that matched the rule.
+	Matched PolicyScope
+	Action  PolicyAction
+}
+
+// Blocked reports whether the decision is to block.
+func (d CountryDecision) Blocked() bool {
+	return d.Action == PolicyBlock
+}
+
+// CountryPolicy is a block or allow list of countries and subdivisions, as
+// used for sanctions and embargo screening. Unlike a RegulatoryClassifier,
+// it considers all of the countries of a record by default, so that, e.g.,
+// networks registered in an embargoed country are blocked wherever they are
+// located.
+//
+// A CountryPolicy is safe for concurrent use.
+type CountryPolicy struct {
+	rules         []CountryRule
+	defaultAction PolicyAction
+}
+
+// NewCountryPolicy returns a CountryPolicy using config. It returns an
+// InvalidCodeError if a rule lists a code that is not a valid ISO 3166-1
+// country code or ISO 3166-2 subdivision code, so that a misspelled code
+// does not silently let requests through.
+func NewCountryPolicy(config CountryPolicyConfig) (*CountryPolicy, error) {
+	rules := make([]CountryRule, len(config.Rules))
+	for i, rule := range config.Rules {
+		if rule.Scope == 0 {
+			rule.Scope = policyAllScopes
+		}
+		rule.Countries = make([]CountryCode, len(rule.Countries))
+		for j, code := range config.Rules[i].Countries {
+			c, err := ParseCountryCode(string(code))
+			if err != nil {
+				return nil, err
+			}
+			rule.Countries[j] = c
+		}
+		rule.Subdivisions = make([]string, len(rule.Subdivisions))
+		for j, code := range config.Rules[i].Subdivisions {
+			sub, err := parseSubdivisionCode(code)
+			if err != nil {
+				return nil, err
+			}
+			rule.Subdivisions[j] = sub
+		}
+		rules[i] = rule
+	}
+	return &CountryPolicy{rules: rules, defaultAction: config.DefaultAction}, nil
+}
+
+// parseSubdivisionCode returns the ISO 3166-2 code s, e.g., "UA-43", in
+// upper case. It returns an InvalidCodeError if s does not consist of a
+// valid country code and one to three letters or digits.
+func parseSubdivisionCode(s string) (string, error) {
+	country, sub, ok := strings.Cut(strings.ToUpper(s), "-")
+	valid := ok && CountryCode(country).IsValid() && len(sub) >= 1 && len(sub) <= 3
+	for _, r := range sub {
+		valid = valid && (r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}
+	if !valid {
+		return "", InvalidCodeError{Code: s, Kind: "subdivision"}
+	}
+	return country + "-" + sub, nil
+}
+
+// Country evaluates the policy against a Country record.
+func (p *CountryPolicy) Country(record *Country) CountryDecision {
+	return p.evaluate(countryRegions(record))
+}
+
+// City evaluates the policy against a City record.
+func (p *CountryPolicy) City(record *City) CountryDecision {
+	return p.evaluate(cityRegions(record))
+}
+
+// Enterprise evaluates the policy against an Enterprise record.
+func (p *CountryPolicy) Enterprise(record *Enterprise) CountryDecision {
+	return p.evaluate(enterpriseRegions(record))
+}
+
+func (p *CountryPolicy) evaluate(record recordRegions) CountryDecision {
+	for i := range p.rules {
+		if matched, value := p.rules[i].match(record); matched != 0 {
+			// The decision gets a copy so that the policy cannot be changed
+			// through it.
+			rule := p.rules[i]
+			rule.Countries = slices.Clone(rule.Countries)
+			rule.Subdivisions = slices.Clone(rule.Subdivisions)
+			return CountryDecision{
+				Rule:    &rule,
+				Value:   value,
+				Matched: matched,
+				Action:  rule.Action,
+			}
+		}
+	}
+	return CountryDecision{Action: p.defaultAction}
+}
+
+func (r *CountryRule) match(record recordRegions) (PolicyScope, string) {
+	if r.Scope&PolicyCountry != 0 {
+		for _, sub := range record.subdivisions {
+			if slices.Contains(r.Subdivisions, sub) {
+				return PolicyCountry, sub
+			}
+		}
+	}
+	for _, c := range []struct {
+		code  CountryCode
+		scope PolicyScope
+	}{
+		{record.country.code, PolicyCountry},
+		{record.registered.code, PolicyRegisteredCountry},
+		{record.represented.code, PolicyRepresentedCountry},
+	} {
+		if c.code != "" && r.Scope&c.scope != 0 && slices.Contains(r.Countries, c.code) {
+			return c.scope, string(c.code)
+		}
+	}
+	return 0, ""
+}
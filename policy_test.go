@@ -0,0 +1,158 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountryPolicy(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	// Located in GB, registered in the US.
+	london, err := reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	p, err := NewCountryPolicy(CountryPolicyConfig{
+		Rules: []CountryRule{
+			{Name: "embargo-us", Countries: []CountryCode{"US"}, Action: PolicyBlock},
+		},
+	})
+	require.NoError(t, err)
+	d := p.City(london)
+	assert.True(t, d.Blocked())
+	require.NotNil(t, d.Rule)
+	assert.Equal(t, "embargo-us", d.Rule.Name)
+	assert.Equal(t, PolicyRegisteredCountry, d.Matched)
+	assert.Equal(t, "US", d.Value)
+
+	p, err = NewCountryPolicy(CountryPolicyConfig{
+		Rules: []CountryRule{
+			{Name: "embargo-us", Countries: []CountryCode{"US"}, Scope: PolicyCountry, Action: PolicyBlock},
+		},
+	})
+	require.NoError(t, err)
+	d = p.City(london)
+	assert.False(t, d.Blocked())
+	assert.Nil(t, d.Rule)
+	assert.Zero(t, d.Matched)
+}
+
+func TestCountryPolicySubdivisions(t *testing.T) {
+	var record Enterprise
+	record.Country.IsoCode = "UA"
+	record.Subdivisions = []EnterpriseSubdivision{{IsoCode: "43"}}
+
+	p, err := NewCountryPolicy(CountryPolicyConfig{
+		Rules: []CountryRule{
+			{Name: "crimea", Subdivisions: []string{"UA-43", "UA-40"}, Action: PolicyBlock},
+			{Name: "allow-ua", Countries: []CountryCode{"UA"}, Action: PolicyAllow},
+		},
+		DefaultAction: PolicyBlock,
+	})
+	require.NoError(t, err)
+
+	d := p.Enterprise(&record)
+	assert.True(t, d.Blocked())
+	assert.Equal(t, "crimea", d.Rule.Name)
+	assert.Equal(t, PolicyCountry, d.Matched)
+	assert.Equal(t, "UA-43", d.Value)
+
+	record.Subdivisions[0].IsoCode = "30"
+	d = p.Enterprise(&record)
+	assert.False(t, d.Blocked())
+	assert.Equal(t, "allow-ua", d.Rule.Name)
+
+	d = p.Enterprise(&Enterprise{})
+	assert.True(t, d.Blocked())
+	assert.Nil(t, d.Rule)
+}
+
+func TestCountryPolicyRepresentedCountry(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Country(net.ParseIP("202.196.224.0"))
+	require.NoError(t, err)
+
+	p, err := NewCountryPolicy(CountryPolicyConfig{
+		Rules: []CountryRule{
+			{
+				Name:      "us-military",
+				Countries: []CountryCode{"US"},
+				Scope:     PolicyRepresentedCountry,
+				Action:    PolicyBlock,
+			},
+		},
+	})
+	require.NoError(t, err)
+	d := p.Country(record)
+	assert.True(t, d.Blocked())
+	assert.Equal(t, PolicyRepresentedCountry, d.Matched)
+	assert.Equal(t, "represented_country", d.Matched.String())
+}
+
+func TestCountryPolicyCodes(t *testing.T) {
+	var record Enterprise
+	record.Country.IsoCode = "UA"
+	record.Subdivisions = []EnterpriseSubdivision{{IsoCode: "43"}}
+
+	p, err := NewCountryPolicy(CountryPolicyConfig{
+		Rules: []CountryRule{
+			{Name: "crimea", Subdivisions: []string{"ua-43"}, Action: PolicyBlock},
+			{Name: "ir", Countries: []CountryCode{"ir"}, Action: PolicyBlock},
+		},
+	})
+	require.NoError(t, err)
+	d := p.Enterprise(&record)
+	assert.True(t, d.Blocked())
+	assert.Equal(t, "UA-43", d.Value)
+	assert.Equal(t, []string{"UA-43"}, d.Rule.Subdivisions)
+
+	record = Enterprise{}
+	record.RegisteredCountry.IsoCode = "IR"
+	assert.True(t, p.Enterprise(&record).Blocked())
+
+	for _, rule := range []CountryRule{
+		{Countries: []CountryCode{"XX"}},
+		{Countries: []CountryCode{"Iran"}},
+		{Subdivisions: []string{"UA"}},
+		{Subdivisions: []string{"XX-43"}},
+		{Subdivisions: []string{"UA-4321"}},
+	} {
+		_, err := NewCountryPolicy(CountryPolicyConfig{Rules: []CountryRule{rule}})
+		var invalid InvalidCodeError
+		assert.ErrorAs(t, err, &invalid, "%+v", rule)
+	}
+}
+
+func TestCountryPolicyDecisionCopiesRule(t *testing.T) {
+	countries := []CountryCode{"GB"}
+	p, err := NewCountryPolicy(CountryPolicyConfig{
+		Rules: []CountryRule{{Name: "gb", Countries: countries, Action: PolicyBlock}},
+	})
+	require.NoError(t, err)
+	countries[0] = "FR"
+
+	var record Country
+	record.Country.IsoCode = "GB"
+	d := p.Country(&record)
+	require.True(t, d.Blocked())
+	d.Rule.Action = PolicyAllow
+	d.Rule.Countries[0] = "FR"
+
+	d = p.Country(&record)
+	assert.True(t, d.Blocked())
+	assert.Equal(t, []CountryCode{"GB"}, d.Rule.Countries)
+}
+
+func TestPolicyStrings(t *testing.T) {
+	assert.Equal(t, "allow", PolicyAllow.String())
+	assert.Equal(t, "block", PolicyBlock.String())
+	assert.Equal(t, "country|registered_country", (PolicyCountry | PolicyRegisteredCountry).String())
+}
@@ -8,12 +8,41 @@
 package geoip2
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/oschwald/maxminddb-golang"
 )
 
+// The Location struct corresponds to the location data in the GeoIP2 and
+// GeoLite2 City and GeoIP2 Enterprise databases.
+type Location struct {
+	TimeZone       string  `maxminddb:"time_zone"`
+	Latitude       float64 `maxminddb:"latitude"`
+	Longitude      float64 `maxminddb:"longitude"`
+	MetroCode      uint    `maxminddb:"metro_code"`
+	AccuracyRadius uint16  `maxminddb:"accuracy_radius"`
+}
+
+// The Subdivision struct corresponds to a subdivision in the GeoIP2 and
+// GeoLite2 City databases.
+type Subdivision struct {
+	Names     map[string]string `maxminddb:"names"`
+	IsoCode   string            `maxminddb:"iso_code"`
+	GeoNameID uint              `maxminddb:"geoname_id"`
+}
+
+// The EnterpriseSubdivision struct corresponds to a subdivision in the
+// GeoIP2 Enterprise database.
+type EnterpriseSubdivision struct {
+	Names      map[string]string `maxminddb:"names"`
+	IsoCode    string            `maxminddb:"iso_code"`
+	GeoNameID  uint              `maxminddb:"geoname_id"`
+	Confidence uint8             `maxminddb:"confidence"`
+}
+
 // The Enterprise struct corresponds to the data in the GeoIP2 Enterprise
 // database.
 type Enterprise struct {
@@ -31,12 +60,7 @@ type Enterprise struct {
 		Code       string `maxminddb:"code"`
 		Confidence uint8  `maxminddb:"confidence"`
 	} `maxminddb:"postal"`
-	Subdivisions []struct {
-		Names      map[string]string `maxminddb:"names"`
-		IsoCode    string            `maxminddb:"iso_code"`
-		GeoNameID  uint              `maxminddb:"geoname_id"`
-		Confidence uint8             `maxminddb:"confidence"`
-	} `maxminddb:"subdivisions"`
+	Subdivisions       []EnterpriseSubdivision `maxminddb:"subdivisions"`
 	RepresentedCountry struct {
 		Names             map[string]string `maxminddb:"names"`
 		IsoCode           string            `maxminddb:"iso_code"`
@@ -74,13 +98,7 @@ type Enterprise struct {
 		IsLegitimateProxy            bool    `maxminddb:"is_legitimate_proxy"`
 		IsSatelliteProvider          bool    `maxminddb:"is_satellite_provider"`
 	} `maxminddb:"traits"`
-	Location struct {
-		TimeZone       string  `maxminddb:"time_zone"`
-		Latitude       float64 `maxminddb:"latitude"`
-		Longitude      float64 `maxminddb:"longitude"`
-		MetroCode      uint    `maxminddb:"metro_code"`
-		AccuracyRadius uint16  `maxminddb:"accuracy_radius"`
-	} `maxminddb:"location"`
+	Location Location `maxminddb:"location"`
 }
 
 // The City struct corresponds to the data in the GeoIP2/GeoLite2 City
@@ -98,11 +116,7 @@ type City struct {
 		Code      string            `maxminddb:"code"`
 		GeoNameID uint              `maxminddb:"geoname_id"`
 	} `maxminddb:"continent"`
-	Subdivisions []struct {
-		Names     map[string]string `maxminddb:"names"`
-		IsoCode   string            `maxminddb:"iso_code"`
-		GeoNameID uint              `maxminddb:"geoname_id"`
-	} `maxminddb:"subdivisions"`
+	Subdivisions       []Subdivision `maxminddb:"subdivisions"`
 	RepresentedCountry struct {
 		Names             map[string]string `maxminddb:"names"`
 		IsoCode           string            `maxminddb:"iso_code"`
@@ -122,14 +136,8 @@ type City struct {
 		GeoNameID         uint              `maxminddb:"geoname_id"`
 		IsInEuropeanUnion bool              `maxminddb:"is_in_european_union"`
 	} `maxminddb:"registered_country"`
-	Location struct {
-		TimeZone       string  `maxminddb:"time_zone"`
-		Latitude       float64 `maxminddb:"latitude"`
-		Longitude      float64 `maxminddb:"longitude"`
-		MetroCode      uint    `maxminddb:"metro_code"`
-		AccuracyRadius uint16  `maxminddb:"accuracy_radius"`
-	} `maxminddb:"location"`
-	Traits struct {
+	Location Location `maxminddb:"location"`
+	Traits   struct {
 		IsAnonymousProxy    bool `maxminddb:"is_anonymous_proxy"`
 		IsAnycast           bool `maxminddb:"is_anycast"`
 		IsSatelliteProvider bool `maxminddb:"is_satellite_provider"`
@@ -221,10 +229,24 @@ const (
 	isISP
 )
 
+// methodTypes maps the names of the lookup methods to the database types
+// that support them.
+var methodTypes = map[string]databaseType{
+	"AnonymousIP":    isAnonymousIP,
+	"ASN":            isASN,
+	"City":           isCity,
+	"ConnectionType": isConnectionType,
+	"Country":        isCountry,
+	"Domain":         isDomain,
+	"Enterprise":     isEnterprise,
+	"ISP":            isISP,
+}
+
 // Reader holds the maxminddb.Reader struct. It can be created using the
 // Open and FromBytes functions.
 type Reader struct {
 	mmdbReader   *maxminddb.Reader
+	observer     Observer
 	databaseType databaseType
 }
 
@@ -261,7 +283,7 @@ func Open(file string) (*Reader, error) {
 		return nil, err
 	}
 	dbType, err := getDBType(reader)
-	return &Reader{reader, dbType}, err
+	return &Reader{mmdbReader: reader, databaseType: dbType}, err
 }
 
 // FromBytes takes a byte slice corresponding to a GeoIP2/GeoLite2 database
@@ -274,7 +296,7 @@ func FromBytes(bytes []byte) (*Reader, error) {
 		return nil, err
 	}
 	dbType, err := getDBType(reader)
-	return &Reader{reader, dbType}, err
+	return &Reader{mmdbReader: reader, databaseType: dbType}, err
 }
 
 func getDBType(reader *maxminddb.Reader) (databaseType, error) {
@@ -315,15 +337,47 @@ func getDBType(reader *maxminddb.Reader) (databaseType, error) {
 	}
 }
 
+// lookup stores the record for ipAddress in each of results and reports
+// whether the database contained a record for it. The search tree is only
+// walked once.
+func (r *Reader) lookup(ipAddress net.IP, results ...any) (bool, error) {
+	offset, found, err := r.lookupOffset(ipAddress)
+	if !found {
+		return false, err
+	}
+	for _, result := range results {
+		if err := r.mmdbReader.Decode(offset, result); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// lookupOffset returns the offset of the record for ipAddress, which can be
+// passed to the Decode method of the maxminddb.Reader, and reports whether
+// the database contained a record for it.
+func (r *Reader) lookupOffset(ipAddress net.IP) (uintptr, bool, error) {
+	offset, err := r.mmdbReader.LookupOffset(ipAddress)
+	if err != nil || offset == maxminddb.NotFound {
+		return 0, false, err
+	}
+	return offset, true, nil
+}
+
 // Enterprise takes an IP address as a net.IP struct and returns an Enterprise
 // struct and/or an error. This is intended to be used with the GeoIP2
 // Enterprise database.
 func (r *Reader) Enterprise(ipAddress net.IP) (*Enterprise, error) {
+	return r.EnterpriseContext(context.Background(), ipAddress)
+}
+
+// EnterpriseContext is like Enterprise but passes ctx to the observer of r.
+func (r *Reader) EnterpriseContext(ctx context.Context, ipAddress net.IP) (*Enterprise, error) {
 	if isEnterprise&r.databaseType == 0 {
 		return nil, InvalidMethodError{"Enterprise", r.Metadata().DatabaseType}
 	}
 	var enterprise Enterprise
-	err := r.mmdbReader.Lookup(ipAddress, &enterprise)
+	_, err := r.observedLookup(ctx, "Enterprise", ipAddress, &enterprise)
 	return &enterprise, err
 }
 
@@ -331,11 +385,16 @@ func (r *Reader) Enterprise(ipAddress net.IP) (*Enterprise, error) {
 // and/or an error. Although this can be used with other databases, this
 // method generally should be used with the GeoIP2 or GeoLite2 City databases.
 func (r *Reader) City(ipAddress net.IP) (*City, error) {
+	return r.CityContext(context.Background(), ipAddress)
+}
+
+// CityContext is like City but passes ctx to the observer of r.
+func (r *Reader) CityContext(ctx context.Context, ipAddress net.IP) (*City, error) {
 	if isCity&r.databaseType == 0 {
 		return nil, InvalidMethodError{"City", r.Metadata().DatabaseType}
 	}
 	var city City
-	err := r.mmdbReader.Lookup(ipAddress, &city)
+	_, err := r.observedLookup(ctx, "City", ipAddress, &city)
 	return &city, err
 }
 
@@ -344,69 +403,135 @@ func (r *Reader) City(ipAddress net.IP) (*City, error) {
 // method generally should be used with the GeoIP2 or GeoLite2 Country
 // databases.
 func (r *Reader) Country(ipAddress net.IP) (*Country, error) {
+	return r.CountryContext(context.Background(), ipAddress)
+}
+
+// CountryContext is like Country but passes ctx to the observer of r.
+func (r *Reader) CountryContext(ctx context.Context, ipAddress net.IP) (*Country, error) {
 	if isCountry&r.databaseType == 0 {
 		return nil, InvalidMethodError{"Country", r.Metadata().DatabaseType}
 	}
 	var country Country
-	err := r.mmdbReader.Lookup(ipAddress, &country)
+	_, err := r.observedLookup(ctx, "Country", ipAddress, &country)
 	return &country, err
 }
 
 // AnonymousIP takes an IP address as a net.IP struct and returns a
 // AnonymousIP struct and/or an error.
 func (r *Reader) AnonymousIP(ipAddress net.IP) (*AnonymousIP, error) {
+	return r.AnonymousIPContext(context.Background(), ipAddress)
+}
+
+// AnonymousIPContext is like AnonymousIP but passes ctx to the observer of r.
+func (r *Reader) AnonymousIPContext(ctx context.Context, ipAddress net.IP) (*AnonymousIP, error) {
 	if isAnonymousIP&r.databaseType == 0 {
 		return nil, InvalidMethodError{"AnonymousIP", r.Metadata().DatabaseType}
 	}
 	var anonIP AnonymousIP
-	err := r.mmdbReader.Lookup(ipAddress, &anonIP)
+	_, err := r.observedLookup(ctx, "AnonymousIP", ipAddress, &anonIP)
 	return &anonIP, err
 }
 
 // ASN takes an IP address as a net.IP struct and returns a ASN struct and/or
 // an error.
 func (r *Reader) ASN(ipAddress net.IP) (*ASN, error) {
+	return r.ASNContext(context.Background(), ipAddress)
+}
+
+// ASNContext is like ASN but passes ctx to the observer of r.
+func (r *Reader) ASNContext(ctx context.Context, ipAddress net.IP) (*ASN, error) {
 	if isASN&r.databaseType == 0 {
 		return nil, InvalidMethodError{"ASN", r.Metadata().DatabaseType}
 	}
 	var val ASN
-	err := r.mmdbReader.Lookup(ipAddress, &val)
+	_, err := r.observedLookup(ctx, "ASN", ipAddress, &val)
 	return &val, err
 }
 
 // ConnectionType takes an IP address as a net.IP struct and returns a
 // ConnectionType struct and/or an error.
 func (r *Reader) ConnectionType(ipAddress net.IP) (*ConnectionType, error) {
+	return r.ConnectionTypeContext(context.Background(), ipAddress)
+}
+
+// ConnectionTypeContext is like ConnectionType but passes ctx to the observer of r.
+func (r *Reader) ConnectionTypeContext(ctx context.Context, ipAddress net.IP) (*ConnectionType, error) {
 	if isConnectionType&r.databaseType == 0 {
 		return nil, InvalidMethodError{"ConnectionType", r.Metadata().DatabaseType}
 	}
 	var val ConnectionType
-	err := r.mmdbReader.Lookup(ipAddress, &val)
+	_, err := r.observedLookup(ctx, "ConnectionType", ipAddress, &val)
 	return &val, err
 }
 
 // Domain takes an IP address as a net.IP struct and returns a
 // Domain struct and/or an error.
 func (r *Reader) Domain(ipAddress net.IP) (*Domain, error) {
+	return r.DomainContext(context.Background(), ipAddress)
+}
+
+// DomainContext is like Domain but passes ctx to the observer of r.
+func (r *Reader) DomainContext(ctx context.Context, ipAddress net.IP) (*Domain, error) {
 	if isDomain&r.databaseType == 0 {
 		return nil, InvalidMethodError{"Domain", r.Metadata().DatabaseType}
 	}
 	var val Domain
-	err := r.mmdbReader.Lookup(ipAddress, &val)
+	_, err := r.observedLookup(ctx, "Domain", ipAddress, &val)
 	return &val, err
 }
 
 // ISP takes an IP address as a net.IP struct and returns a ISP struct and/or
 // an error.
 func (r *Reader) ISP(ipAddress net.IP) (*ISP, error) {
+	return r.ISPContext(context.Background(), ipAddress)
+}
+
+// ISPContext is like ISP but passes ctx to the observer of r.
+func (r *Reader) ISPContext(ctx context.Context, ipAddress net.IP) (*ISP, error) {
 	if isISP&r.databaseType == 0 {
 		return nil, InvalidMethodError{"ISP", r.Metadata().DatabaseType}
 	}
 	var val ISP
-	err := r.mmdbReader.Lookup(ipAddress, &val)
+	_, err := r.observedLookup(ctx, "ISP", ipAddress, &val)
 	return &val, err
 }
 
+// LookupNetwork stores the record for ipAddress in result, which must be a
+// pointer, and returns the network of the record and whether the database
+// contains one. Unlike the other lookup methods, it works with any database
+// type, and result may be any type supported by maxminddb, such as a
+// map[string]any holding the complete record.
+func (r *Reader) LookupNetwork(ipAddress net.IP, result any) (network *net.IPNet, found bool, err error) {
+	return r.LookupNetworkContext(context.Background(), ipAddress, result)
+}
+
+// LookupNetworkContext is like LookupNetwork but passes ctx to the observer
+// of r.
+func (r *Reader) LookupNetworkContext(
+	ctx context.Context,
+	ipAddress net.IP,
+	result any,
+) (network *net.IPNet, found bool, err error) {
+	if r.observer == nil {
+		return r.mmdbReader.LookupNetwork(ipAddress, result)
+	}
+	start := time.Now()
+	network, found, err = r.mmdbReader.LookupNetwork(ipAddress, result)
+	r.notify(ctx, "LookupNetwork", start, found, err)
+	return network, found, err
+}
+
+// Supports reports whether the lookup method named method, e.g., "City" or
+// "ASN", can be used with the database of r, i.e., whether it returns an
+// InvalidMethodError, without performing a lookup. LookupNetwork is
+// supported by every database.
+func (r *Reader) Supports(method string) bool {
+	if method == "LookupNetwork" {
+		return true
+	}
+	return methodTypes[method]&r.databaseType != 0
+}
+
 // Metadata takes no arguments and returns a struct containing metadata about
 // the MaxMind database in use by the Reader.
 func (r *Reader) Metadata() maxminddb.Metadata {
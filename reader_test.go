@@ -255,6 +255,39 @@ func TestISP(t *testing.T) {
 	assert.Equal(t, "Verizon Wireless", record.Organization)
 }
 
+func TestLookupNetwork(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var record map[string]any
+	network, found, err := reader.LookupNetwork(net.ParseIP("81.2.69.160"), &record)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "81.2.69.128/26", network.String())
+	assert.Equal(t, "GB", record["country"].(map[string]any)["iso_code"])
+
+	var city City
+	network, found, err = reader.LookupNetwork(net.ParseIP("10.0.0.1"), &city)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.NotNil(t, network)
+	assert.Empty(t, city.Country.IsoCode)
+}
+
+func TestSupports(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	assert.True(t, reader.Supports("City"))
+	assert.True(t, reader.Supports("Country"))
+	assert.True(t, reader.Supports("LookupNetwork"))
+	assert.False(t, reader.Supports("ASN"))
+	assert.False(t, reader.Supports("Enterprise"))
+	assert.False(t, reader.Supports("Unknown"))
+}
+
 // This ensures the compiler does not optimize away the function call.
 var cityResult *City
 
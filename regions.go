@@ -0,0 +1,47 @@
+package geoip2
+
+// regionCountry is a country of a record, as used by the classifiers that
+// work on the regions of a record.
+type regionCountry struct {
+	code CountryCode
+	isEU bool
+}
+
+func newRegionCountry(code string, isEU bool) regionCountry {
+	return regionCountry{code: CountryCode(code), isEU: isEU}
+}
+
+// recordRegions holds the regions of a City, Country or Enterprise record.
+// subdivisions are full ISO 3166-2 codes of the subdivisions of country.
+type recordRegions struct {
+	subdivisions []string
+	country      regionCountry
+	registered   regionCountry
+	represented  regionCountry
+}
+
+func countryRegions(record *Country) recordRegions {
+	return recordRegions{
+		country:     newRegionCountry(record.Country.IsoCode, record.Country.IsInEuropeanUnion),
+		registered:  newRegionCountry(record.RegisteredCountry.IsoCode, record.RegisteredCountry.IsInEuropeanUnion),
+		represented: newRegionCountry(record.RepresentedCountry.IsoCode, record.RepresentedCountry.IsInEuropeanUnion),
+	}
+}
+
+func cityRegions(record *City) recordRegions {
+	return recordRegions{
+		subdivisions: record.SubdivisionISOCodes(),
+		country:      newRegionCountry(record.Country.IsoCode, record.Country.IsInEuropeanUnion),
+		registered:   newRegionCountry(record.RegisteredCountry.IsoCode, record.RegisteredCountry.IsInEuropeanUnion),
+		represented:  newRegionCountry(record.RepresentedCountry.IsoCode, record.RepresentedCountry.IsInEuropeanUnion),
+	}
+}
+
+func enterpriseRegions(record *Enterprise) recordRegions {
+	return recordRegions{
+		subdivisions: record.SubdivisionISOCodes(),
+		country:      newRegionCountry(record.Country.IsoCode, record.Country.IsInEuropeanUnion),
+		registered:   newRegionCountry(record.RegisteredCountry.IsoCode, record.RegisteredCountry.IsInEuropeanUnion),
+		represented:  newRegionCountry(record.RepresentedCountry.IsoCode, record.RepresentedCountry.IsInEuropeanUnion),
+	}
+}
@@ -0,0 +1,194 @@
+package geoip2
+
+import "slices"
+
+// Regime is a regulatory regime, such as a privacy law, that may apply to
+// users in a region.
+type Regime string
+
+// The regimes of DefaultRegulatoryRules.
+const (
+	// RegimeEEA is membership in the European Economic Area, i.e., the EU
+	// plus Iceland, Liechtenstein and Norway.
+	RegimeEEA Regime = "EEA"
+	// RegimeGDPR is the EU General Data Protection Regulation, which
+	// applies in the EEA.
+	RegimeGDPR Regime = "GDPR"
+	// RegimeUKGDPR is the UK General Data Protection Regulation.
+	RegimeUKGDPR Regime = "UK GDPR"
+	// RegimeSwissFADP is the Swiss Federal Act on Data Protection.
+	RegimeSwissFADP Regime = "FADP"
+	// RegimeCCPA is the California Consumer Privacy Act.
+	RegimeCCPA Regime = "CCPA"
+	// RegimeVCDPA is the Virginia Consumer Data Protection Act.
+	RegimeVCDPA Regime = "VCDPA"
+	// RegimeCPA is the Colorado Privacy Act.
+	RegimeCPA Regime = "CPA"
+	// RegimeCTDPA is the Connecticut Data Privacy Act.
+	RegimeCTDPA Regime = "CTDPA"
+	// RegimeUCPA is the Utah Consumer Privacy Act.
+	RegimeUCPA Regime = "UCPA"
+	// RegimeTDPSA is the Texas Data Privacy and Security Act.
+	RegimeTDPSA Regime = "TDPSA"
+	// RegimeOCPA is the Oregon Consumer Privacy Act.
+	RegimeOCPA Regime = "OCPA"
+)
+
+// RegulatoryRule maps a region to a Regime. A rule matches a country if the
+// country is listed in Countries or if EuropeanUnion is set and the record
+// reports the country as being in the EU. It matches a subdivision if the
+// subdivision's full ISO 3166-2 code, e.g., "US-CA", is listed in
+// Subdivisions.
+type RegulatoryRule struct {
+	Regime        Regime
+	Countries     []CountryCode
+	Subdivisions  []string
+	EuropeanUnion bool
+}
+
+var euCountries = []CountryCode{
+	"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR",
+	"HR", "HU", "IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO",
+	"SE", "SI", "SK",
+}
+
+var eeaCountries = append(slices.Clone(euCountries), "IS", "LI", "NO")
+
+// DefaultRegulatoryRules are the rules used by a RegulatoryClassifier when
+// RegulatoryConfig.Rules is nil. They are a starting point rather than
+// legal advice; copy and adjust them as needed.
+var DefaultRegulatoryRules = []RegulatoryRule{
+	{Regime: RegimeEEA, Countries: eeaCountries, EuropeanUnion: true},
+	{Regime: RegimeGDPR, Countries: eeaCountries, EuropeanUnion: true},
+	{Regime: RegimeUKGDPR, Countries: []CountryCode{"GB"}},
+	{Regime: RegimeSwissFADP, Countries: []CountryCode{"CH"}},
+	{Regime: RegimeCCPA, Subdivisions: []string{"US-CA"}},
+	{Regime: RegimeVCDPA, Subdivisions: []string{"US-VA"}},
+	{Regime: RegimeCPA, Subdivisions: []string{"US-CO"}},
+	{Regime: RegimeCTDPA, Subdivisions: []string{"US-CT"}},
+	{Regime: RegimeUCPA, Subdivisions: []string{"US-UT"}},
+	{Regime: RegimeTDPSA, Subdivisions: []string{"US-TX"}},
+	{Regime: RegimeOCPA, Subdivisions: []string{"US-OR"}},
+}
+
+// RegulatoryPrecedence determines which of the countries of a record are
+// used by a RegulatoryClassifier.
+type RegulatoryPrecedence int
+
+const (
+	// PreferPhysicalCountry uses the country where the IP address is
+	// located, Country, and its subdivisions. RegisteredCountry is only
+	// used if the record has no Country.
+	PreferPhysicalCountry RegulatoryPrecedence = iota
+	// PreferRegisteredCountry uses the country in which the network is
+	// registered, RegisteredCountry. Country and its subdivisions are only
+	// used if the record has no RegisteredCountry.
+	PreferRegisteredCountry
+	// AllCountries uses Country, its subdivisions and RegisteredCountry,
+	// applying every regime that any of them calls for. This is the most
+	// conservative choice.
+	AllCountries
+)
+
+// RegulatoryConfig configures a RegulatoryClassifier.
+type RegulatoryConfig struct {
+	// Rules are the rules used for classification. If nil,
+	// DefaultRegulatoryRules is used.
+	Rules []RegulatoryRule
+	// Precedence chooses between the physical and registered countries.
+	Precedence RegulatoryPrecedence
+}
+
+// Regimes is a set of regimes, in the order of the rules that matched.
+type Regimes []Regime
+
+// Has reports whether regime is in the set.
+func (r Regimes) Has(regime Regime) bool {
+	return slices.Contains(r, regime)
+}
+
+// RegulatoryClassifier returns the regimes that apply to the records of
+// lookups.
+//
+// The countries of a record are chosen according to the configured
+// RegulatoryPrecedence. Regardless of it, the RepresentedCountry of a
+// record, e.g., the country of a military base, is always used in addition,
+// as the network belongs to an entity of that country. Subdivision rules are
+// only matched against the subdivisions of the physical country.
+type RegulatoryClassifier struct {
+	rules      []RegulatoryRule
+	precedence RegulatoryPrecedence
+}
+
+// NewRegulatoryClassifier returns a RegulatoryClassifier using config.
+func NewRegulatoryClassifier(config RegulatoryConfig) *RegulatoryClassifier {
+	if config.Rules == nil {
+		config.Rules = DefaultRegulatoryRules
+	}
+	return &RegulatoryClassifier{rules: config.Rules, precedence: config.Precedence}
+}
+
+// Country returns the regimes that apply to a Country record.
+func (c *RegulatoryClassifier) Country(record *Country) Regimes {
+	return c.classify(countryRegions(record))
+}
+
+// City returns the regimes that apply to a City record.
+func (c *RegulatoryClassifier) City(record *City) Regimes {
+	return c.classify(cityRegions(record))
+}
+
+// Enterprise returns the regimes that apply to an Enterprise record.
+func (c *RegulatoryClassifier) Enterprise(record *Enterprise) Regimes {
+	return c.classify(enterpriseRegions(record))
+}
+
+func (c *RegulatoryClassifier) classify(record recordRegions) Regimes {
+	usePhysical := record.country.code != ""
+	useRegistered := record.registered.code != ""
+	switch c.precedence {
+	case PreferPhysicalCountry:
+		useRegistered = useRegistered && !usePhysical
+	case PreferRegisteredCountry:
+		usePhysical = usePhysical && !useRegistered
+	case AllCountries:
+	}
+
+	var countries []regionCountry
+	var subdivisions []string
+	if usePhysical {
+		countries = append(countries, record.country)
+		subdivisions = record.subdivisions
+	}
+	if useRegistered {
+		countries = append(countries, record.registered)
+	}
+	if record.represented.code != "" {
+		countries = append(countries, record.represented)
+	}
+
+	var regimes Regimes
+	for _, rule := range c.rules {
+		if regimes.Has(rule.Regime) {
+			continue
+		}
+		if rule.matches(countries, subdivisions) {
+			regimes = append(regimes, rule.Regime)
+		}
+	}
+	return regimes
+}
+
+func (r *RegulatoryRule) matches(countries []regionCountry, subdivisions []string) bool {
+	for _, country := range countries {
+		if (r.EuropeanUnion && country.isEU) || slices.Contains(r.Countries, country.code) {
+			return true
+		}
+	}
+	for _, sub := range subdivisions {
+		if slices.Contains(r.Subdivisions, sub) {
+			return true
+		}
+	}
+	return false
+}
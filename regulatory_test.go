@@ -0,0 +1,92 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegulatoryClassifierCity(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	// Located in GB, registered in the US.
+	london, err := reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	sweden, err := reader.City(net.ParseIP("89.160.20.112"))
+	require.NoError(t, err)
+
+	c := NewRegulatoryClassifier(RegulatoryConfig{})
+	assert.Equal(t, Regimes{RegimeUKGDPR}, c.City(london))
+	assert.Equal(t, Regimes{RegimeEEA, RegimeGDPR}, c.City(sweden))
+
+	c = NewRegulatoryClassifier(RegulatoryConfig{Precedence: PreferRegisteredCountry})
+	assert.Empty(t, c.City(london))
+
+	c = NewRegulatoryClassifier(RegulatoryConfig{Precedence: AllCountries})
+	assert.Equal(t, Regimes{RegimeUKGDPR}, c.City(london))
+
+	empty, err := reader.City(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Empty(t, c.City(empty))
+}
+
+func TestRegulatoryClassifierSubdivisions(t *testing.T) {
+	var record City
+	record.Country.IsoCode = "US"
+	record.RegisteredCountry.IsoCode = "DE"
+	record.RegisteredCountry.IsInEuropeanUnion = true
+	record.Subdivisions = []Subdivision{{IsoCode: "CA"}}
+
+	c := NewRegulatoryClassifier(RegulatoryConfig{})
+	regimes := c.City(&record)
+	assert.Equal(t, Regimes{RegimeCCPA}, regimes)
+	assert.True(t, regimes.Has(RegimeCCPA))
+	assert.False(t, regimes.Has(RegimeGDPR))
+
+	// Subdivisions belong to the physical country, so they are ignored
+	// when the registered country takes precedence.
+	c = NewRegulatoryClassifier(RegulatoryConfig{Precedence: PreferRegisteredCountry})
+	assert.Equal(t, Regimes{RegimeEEA, RegimeGDPR}, c.City(&record))
+
+	c = NewRegulatoryClassifier(RegulatoryConfig{Precedence: AllCountries})
+	assert.Equal(t, Regimes{RegimeEEA, RegimeGDPR, RegimeCCPA}, c.City(&record))
+
+	// Without a physical country, the registered country is used.
+	record.Country.IsoCode = ""
+	c = NewRegulatoryClassifier(RegulatoryConfig{})
+	assert.Equal(t, Regimes{RegimeEEA, RegimeGDPR}, c.City(&record))
+}
+
+func TestRegulatoryClassifierRepresentedCountry(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Country-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Country(net.ParseIP("202.196.224.0"))
+	require.NoError(t, err)
+	require.Equal(t, "US", record.RepresentedCountry.IsoCode)
+
+	c := NewRegulatoryClassifier(RegulatoryConfig{
+		Rules: []RegulatoryRule{
+			{Regime: "US federal", Countries: []CountryCode{"US"}},
+			{Regime: "PH DPA", Countries: []CountryCode{"PH"}},
+		},
+	})
+	assert.Equal(t, Regimes{"US federal", "PH DPA"}, c.Country(record))
+}
+
+func TestRegulatoryClassifierEnterprise(t *testing.T) {
+	var record Enterprise
+	record.Country.IsoCode = "CH"
+	record.Subdivisions = []EnterpriseSubdivision{{IsoCode: "ZH"}}
+
+	c := NewRegulatoryClassifier(RegulatoryConfig{})
+	assert.Equal(t, Regimes{RegimeSwissFADP}, c.Enterprise(&record))
+
+	record.Country.IsoCode = "NO"
+	assert.Equal(t, Regimes{RegimeEEA, RegimeGDPR}, c.Enterprise(&record))
+}
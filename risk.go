@@ -0,0 +1,173 @@
+package geoip2
+
+// RiskSignal is a property of an IP address that contributes to its
+// anonymizer risk score.
+type RiskSignal string
+
+// The signals used by a RiskScorer and the data they are derived from.
+const (
+	// RiskTorExitNode is AnonymousIP.IsTorExitNode.
+	RiskTorExitNode RiskSignal = "tor_exit_node"
+	// RiskPublicProxy is AnonymousIP.IsPublicProxy.
+	RiskPublicProxy RiskSignal = "public_proxy"
+	// RiskResidentialProxy is AnonymousIP.IsResidentialProxy.
+	RiskResidentialProxy RiskSignal = "residential_proxy"
+	// RiskAnonymousVPN is AnonymousIP.IsAnonymousVPN.
+	RiskAnonymousVPN RiskSignal = "anonymous_vpn"
+	// RiskHostingProvider is AnonymousIP.IsHostingProvider.
+	RiskHostingProvider RiskSignal = "hosting_provider"
+	// RiskAnonymous is AnonymousIP.IsAnonymous, which is set along with
+	// any of the more specific flags.
+	RiskAnonymous RiskSignal = "anonymous"
+	// RiskAnonymousProxy is Enterprise.Traits.IsAnonymousProxy.
+	RiskAnonymousProxy RiskSignal = "anonymous_proxy"
+	// RiskPrivacyNetwork is a UserTypeConsumerPrivacyNetwork user type.
+	RiskPrivacyNetwork RiskSignal = "consumer_privacy_network"
+	// RiskHostingUserType is a user type for which UserType.IsHosting
+	// reports true.
+	RiskHostingUserType RiskSignal = "hosting_user_type"
+	// RiskCorporateConnection is a ConnectionCorporate connection type,
+	// which includes data centers.
+	RiskCorporateConnection RiskSignal = "corporate_connection"
+	// RiskDynamicIP is an Enterprise.Traits.StaticIPScore below
+	// RiskConfig.DynamicIPThreshold.
+	RiskDynamicIP RiskSignal = "dynamic_ip"
+	// RiskLegitimateProxy is Enterprise.Traits.IsLegitimateProxy, e.g., a
+	// corporate proxy. Its default weight is negative.
+	RiskLegitimateProxy RiskSignal = "legitimate_proxy"
+)
+
+// RiskWeights maps signals to the amount they add to a risk score.
+type RiskWeights map[RiskSignal]float64
+
+// DefaultRiskWeights are the weights used by a RiskScorer when
+// RiskConfig.Weights is nil.
+var DefaultRiskWeights = RiskWeights{
+	RiskTorExitNode:         60,
+	RiskPublicProxy:         50,
+	RiskResidentialProxy:    45,
+	RiskAnonymousVPN:        35,
+	RiskHostingProvider:     25,
+	RiskAnonymous:           10,
+	RiskAnonymousProxy:      40,
+	RiskPrivacyNetwork:      30,
+	RiskHostingUserType:     15,
+	RiskCorporateConnection: 5,
+	RiskDynamicIP:           5,
+	RiskLegitimateProxy:     -30,
+}
+
+// DefaultDynamicIPThreshold is the StaticIPScore below which an IP address
+// is considered dynamic when RiskConfig.DynamicIPThreshold is zero.
+const DefaultDynamicIPThreshold = 1.0
+
+// MaxRiskScore is the highest risk score.
+const MaxRiskScore = 100
+
+// RiskConfig configures a RiskScorer.
+type RiskConfig struct {
+	// Weights are the weights of the signals. Signals without a weight
+	// are ignored. If nil, DefaultRiskWeights is used.
+	Weights RiskWeights
+	// DynamicIPThreshold is the StaticIPScore below which RiskDynamicIP is
+	// reported. A score of zero is treated as missing. If zero,
+	// DefaultDynamicIPThreshold is used.
+	DynamicIPThreshold float64
+}
+
+// RiskContribution is a signal that contributed to a risk score.
+type RiskContribution struct {
+	Signal RiskSignal
+	Weight float64
+}
+
+// RiskScore is an anonymizer risk score along with the signals that
+// contributed to it.
+type RiskScore struct {
+	// Contributions are the signals that were present and have a weight,
+	// in the order of the constants above.
+	Contributions []RiskContribution
+	// Score is the sum of the weights of Contributions, limited to the
+	// range from 0 to MaxRiskScore.
+	Score float64
+}
+
+// RiskScorer computes anonymizer risk scores from the results of the
+// Anonymous IP and Enterprise databases. A RiskScorer is safe for
+// concurrent use.
+type RiskScorer struct {
+	weights            RiskWeights
+	dynamicIPThreshold float64
+}
+
+// NewRiskScorer returns a RiskScorer using config.
+func NewRiskScorer(config RiskConfig) *RiskScorer {
+	if config.Weights == nil {
+		config.Weights = DefaultRiskWeights
+	}
+	if config.DynamicIPThreshold == 0 {
+		config.DynamicIPThreshold = DefaultDynamicIPThreshold
+	}
+	return &RiskScorer{
+		weights:            config.Weights,
+		dynamicIPThreshold: config.DynamicIPThreshold,
+	}
+}
+
+// Score returns the risk score of an IP address given its records from the
+// Anonymous IP and Enterprise databases. Either record may be nil if the
+// corresponding database is not available.
+func (s *RiskScorer) Score(anonymousIP *AnonymousIP, enterprise *Enterprise) RiskScore {
+	var signals []RiskSignal
+	if anonymousIP != nil {
+		for _, flag := range []struct {
+			signal RiskSignal
+			set    bool
+		}{
+			{RiskTorExitNode, anonymousIP.IsTorExitNode},
+			{RiskPublicProxy, anonymousIP.IsPublicProxy},
+			{RiskResidentialProxy, anonymousIP.IsResidentialProxy},
+			{RiskAnonymousVPN, anonymousIP.IsAnonymousVPN},
+			{RiskHostingProvider, anonymousIP.IsHostingProvider},
+			{RiskAnonymous, anonymousIP.IsAnonymous},
+		} {
+			if flag.set {
+				signals = append(signals, flag.signal)
+			}
+		}
+	}
+	if enterprise != nil {
+		traits := &enterprise.Traits
+		if traits.IsAnonymousProxy {
+			signals = append(signals, RiskAnonymousProxy)
+		}
+		userType := UserType(traits.UserType)
+		if userType == UserTypeConsumerPrivacyNetwork {
+			signals = append(signals, RiskPrivacyNetwork)
+		}
+		if userType.IsHosting() {
+			signals = append(signals, RiskHostingUserType)
+		}
+		if ConnectionKind(traits.ConnectionType) == ConnectionCorporate {
+			signals = append(signals, RiskCorporateConnection)
+		}
+		if traits.StaticIPScore > 0 && traits.StaticIPScore < s.dynamicIPThreshold {
+			signals = append(signals, RiskDynamicIP)
+		}
+		if traits.IsLegitimateProxy {
+			signals = append(signals, RiskLegitimateProxy)
+		}
+	}
+
+	var score RiskScore
+	for _, signal := range signals {
+		weight, ok := s.weights[signal]
+		if !ok || weight == 0 {
+			continue
+		}
+		score.Contributions = append(score.Contributions, RiskContribution{signal, weight})
+		score.Score += weight
+	}
+	score.Score = min(max(score.Score, 0), MaxRiskScore)
+	return score
+}
@@ -0,0 +1,90 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskScorer(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Anonymous-IP-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.AnonymousIP(net.ParseIP("81.2.69.0"))
+	require.NoError(t, err)
+
+	s := NewRiskScorer(RiskConfig{})
+	score := s.Score(record, nil)
+	assert.InDelta(t, float64(MaxRiskScore), score.Score, 0)
+	signals := make([]RiskSignal, len(score.Contributions))
+	for i, c := range score.Contributions {
+		signals[i] = c.Signal
+	}
+	assert.Equal(t, []RiskSignal{
+		RiskTorExitNode,
+		RiskPublicProxy,
+		RiskResidentialProxy,
+		RiskAnonymousVPN,
+		RiskHostingProvider,
+		RiskAnonymous,
+	}, signals)
+
+	record, err = reader.AnonymousIP(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Equal(t, RiskScore{}, s.Score(record, nil))
+	assert.Equal(t, RiskScore{}, s.Score(nil, nil))
+}
+
+func TestRiskScorerEnterprise(t *testing.T) {
+	anonymousIP := &AnonymousIP{IsAnonymous: true, IsHostingProvider: true}
+	var enterprise Enterprise
+	enterprise.Traits.UserType = string(UserTypeHosting)
+	enterprise.Traits.ConnectionType = string(ConnectionCorporate)
+	enterprise.Traits.StaticIPScore = 0.5
+
+	s := NewRiskScorer(RiskConfig{})
+	score := s.Score(anonymousIP, &enterprise)
+	assert.Equal(t, []RiskContribution{
+		{RiskHostingProvider, 25},
+		{RiskAnonymous, 10},
+		{RiskHostingUserType, 15},
+		{RiskCorporateConnection, 5},
+		{RiskDynamicIP, 5},
+	}, score.Contributions)
+	assert.InDelta(t, 60.0, score.Score, 1e-9)
+
+	// A legitimate proxy reduces the score, but never below zero.
+	enterprise.Traits.IsLegitimateProxy = true
+	score = s.Score(nil, &enterprise)
+	assert.InDelta(t, 0.0, score.Score, 0)
+	assert.Equal(t, RiskLegitimateProxy, score.Contributions[len(score.Contributions)-1].Signal)
+
+	s = NewRiskScorer(RiskConfig{
+		Weights:            RiskWeights{RiskDynamicIP: 20, RiskPrivacyNetwork: 70},
+		DynamicIPThreshold: 0.1,
+	})
+	enterprise.Traits.UserType = string(UserTypeConsumerPrivacyNetwork)
+	score = s.Score(anonymousIP, &enterprise)
+	assert.Equal(t, []RiskContribution{{RiskPrivacyNetwork, 70}}, score.Contributions)
+
+	enterprise.Traits.StaticIPScore = 0.05
+	score = s.Score(anonymousIP, &enterprise)
+	assert.InDelta(t, 90.0, score.Score, 1e-9)
+}
+
+func TestRiskScorerEnterpriseDatabase(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Enterprise-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Enterprise(net.ParseIP("74.209.24.0"))
+	require.NoError(t, err)
+
+	// A residential broadband connection with a dynamic IP address.
+	score := NewRiskScorer(RiskConfig{}).Score(nil, record)
+	assert.Equal(t, []RiskContribution{{RiskDynamicIP, 5}}, score.Contributions)
+	assert.InDelta(t, 5.0, score.Score, 1e-9)
+}
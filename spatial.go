@@ -0,0 +1,207 @@
+package geoip2
+
+import (
+	"container/heap"
+	"math"
+	"net"
+	"sort"
+)
+
+// SpatialMatch is a network returned by a SpatialIndex query.
+type SpatialMatch struct {
+	Network *net.IPNet
+	// City is the record of the network. Networks that share a record in
+	// the database share the same City value.
+	City *City
+	// Distance is the distance in kilometers between the query point and
+	// the coordinates of the network. The accuracy radius is not taken
+	// into account.
+	Distance float64
+}
+
+type spatialPoint struct {
+	networks  []*net.IPNet
+	latitude  float64
+	longitude float64
+	offset    uintptr
+}
+
+// SpatialIndex is an index of the networks in a City or Enterprise database
+// by their coordinates. It answers radius and k-nearest queries without
+// iterating over the database again.
+//
+// Records are decoded from the Reader the index was built from when a query
+// returns them, so the Reader must not be closed while the index is in use.
+// A SpatialIndex is safe for concurrent use.
+type SpatialIndex struct {
+	reader *Reader
+	// points are sorted by latitude.
+	points []spatialPoint
+}
+
+// NewSpatialIndex builds a SpatialIndex by iterating once over the networks
+// in r. Networks without coordinates, such as anycast networks, are not
+// indexed.
+func NewSpatialIndex(r *Reader) (*SpatialIndex, error) {
+	if isCity&r.databaseType == 0 {
+		return nil, InvalidMethodError{"NewSpatialIndex", r.Metadata().DatabaseType}
+	}
+
+	var points []spatialPoint
+	byOffset := map[uintptr]int{}
+	networks := r.Networks()
+	for networks.Next() {
+		var record struct {
+			Location Location `maxminddb:"location"`
+		}
+		network, err := networks.Network(&record)
+		if err != nil {
+			return nil, err
+		}
+		if !record.Location.HasCoordinates() {
+			continue
+		}
+		offset, err := r.mmdbReader.LookupOffset(network.IP)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := byOffset[offset]
+		if !ok {
+			i = len(points)
+			byOffset[offset] = i
+			points = append(points, spatialPoint{
+				latitude:  record.Location.Latitude,
+				longitude: record.Location.Longitude,
+				offset:    offset,
+			})
+		}
+		points[i].networks = append(points[i].networks, network)
+	}
+	if err := networks.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].latitude < points[j].latitude
+	})
+	return &SpatialIndex{reader: r, points: points}, nil
+}
+
+// Within returns the networks whose coordinates are within radius
+// kilometers of the point at latitude and longitude, nearest first.
+func (s *SpatialIndex) Within(latitude, longitude, radius float64) ([]SpatialMatch, error) {
+	box := BoundingBoxAround(latitude, longitude, radius)
+	i := sort.Search(len(s.points), func(i int) bool {
+		return s.points[i].latitude >= box.MinLatitude
+	})
+
+	var candidates []spatialCandidate
+	for ; i < len(s.points) && s.points[i].latitude <= box.MaxLatitude; i++ {
+		p := &s.points[i]
+		if !box.Contains(p.latitude, p.longitude) {
+			continue
+		}
+		distance := haversine(latitude, longitude, p.latitude, p.longitude)
+		if distance <= radius {
+			candidates = append(candidates, spatialCandidate{p, distance})
+		}
+	}
+	return s.matches(candidates, -1)
+}
+
+// Nearest returns the k networks whose coordinates are nearest to the point
+// at latitude and longitude, nearest first. It returns fewer networks if
+// the index contains fewer than k.
+func (s *SpatialIndex) Nearest(latitude, longitude float64, k int) ([]SpatialMatch, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+
+	// Points are visited in order of increasing latitude difference, which
+	// bounds their distance from below. candidates is a max-heap holding
+	// the nearest points seen so far, trimmed so that it holds no more
+	// points than needed for k networks.
+	above := sort.Search(len(s.points), func(i int) bool {
+		return s.points[i].latitude >= latitude
+	})
+	below := above - 1
+	var candidates spatialCandidates
+	count := 0
+	for below >= 0 || above < len(s.points) {
+		var p *spatialPoint
+		if above == len(s.points) ||
+			(below >= 0 && latitude-s.points[below].latitude < s.points[above].latitude-latitude) {
+			p = &s.points[below]
+			below--
+		} else {
+			p = &s.points[above]
+			above++
+		}
+
+		bound := math.Abs(p.latitude-latitude) * math.Pi / 180 * EarthRadius
+		if count >= k && bound > candidates[0].distance {
+			break
+		}
+		distance := haversine(latitude, longitude, p.latitude, p.longitude)
+		if count >= k && distance >= candidates[0].distance {
+			continue
+		}
+		heap.Push(&candidates, spatialCandidate{p, distance})
+		count += len(p.networks)
+		for count-len(candidates[0].point.networks) >= k {
+			count -= len(heap.Pop(&candidates).(spatialCandidate).point.networks)
+		}
+	}
+	return s.matches(candidates, k)
+}
+
+// matches sorts candidates by distance and returns up to limit of their
+// networks, or all of them if limit is negative.
+func (s *SpatialIndex) matches(candidates []spatialCandidate, limit int) ([]SpatialMatch, error) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var matches []SpatialMatch
+	for _, c := range candidates {
+		var city City
+		if err := s.reader.mmdbReader.Decode(c.point.offset, &city); err != nil {
+			return nil, err
+		}
+		for _, network := range c.point.networks {
+			if len(matches) == limit {
+				return matches, nil
+			}
+			matches = append(matches, SpatialMatch{
+				Network:  network,
+				City:     &city,
+				Distance: c.distance,
+			})
+		}
+	}
+	return matches, nil
+}
+
+type spatialCandidate struct {
+	point    *spatialPoint
+	distance float64
+}
+
+// spatialCandidates is a max-heap of candidates by distance.
+type spatialCandidates []spatialCandidate
+
+func (c spatialCandidates) Len() int           { return len(c) }
+func (c spatialCandidates) Less(i, j int) bool { return c[i].distance > c[j].distance }
+func (c spatialCandidates) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+func (c *spatialCandidates) Push(x any) {
+	*c = append(*c, x.(spatialCandidate))
+}
+
+func (c *spatialCandidates) Pop() any {
+	old := *c
+	n := len(old)
+	x := old[n-1]
+	*c = old[:n-1]
+	return x
+}
@@ -0,0 +1,91 @@
+package geoip2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpatialIndexWithin(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	index, err := NewSpatialIndex(reader)
+	require.NoError(t, err)
+
+	matches, err := index.Within(51.5, -0.1, 50)
+	require.NoError(t, err)
+	require.NotEmpty(t, matches)
+	for _, m := range matches {
+		assert.Equal(t, "London", m.City.City.Names["en"])
+		assert.LessOrEqual(t, m.Distance, 50.0)
+		assert.True(t, m.Network.Contains(m.Network.IP))
+	}
+	assert.Contains(t, networkStrings(matches), "81.2.69.128/26")
+
+	matches, err = index.Within(51.5, -0.1, 100)
+	require.NoError(t, err)
+	assert.Contains(t, networkStrings(matches), "2.125.160.216/29")
+	for i := 1; i < len(matches); i++ {
+		assert.LessOrEqual(t, matches[i-1].Distance, matches[i].Distance)
+	}
+
+	matches, err = index.Within(0, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestSpatialIndexNearest(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	index, err := NewSpatialIndex(reader)
+	require.NoError(t, err)
+
+	matches, err := index.Nearest(47.2, -122.3, 1)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "Milton", matches[0].City.City.Names["en"])
+
+	matches, err = index.Nearest(51.5, -0.1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	// Every network is within half the circumference of the Earth, so
+	// Nearest and Within must agree on the order of all of them.
+	points := [][2]float64{{51.5, -0.1}, {-33.9, 151.2}, {89, 0}, {0, 179.9}}
+	for _, p := range points {
+		all, err := index.Within(p[0], p[1], 20100)
+		require.NoError(t, err)
+		require.NotEmpty(t, all)
+
+		for _, k := range []int{1, 2, len(all), len(all) + 10} {
+			matches, err := index.Nearest(p[0], p[1], k)
+			require.NoError(t, err)
+			require.Len(t, matches, min(k, len(all)))
+			for i, m := range matches {
+				assert.InDelta(t, all[i].Distance, m.Distance, 1e-9, "%v k=%d", p, k)
+			}
+		}
+	}
+}
+
+func TestSpatialIndexInvalidMethod(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoLite2-ASN-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, err = NewSpatialIndex(reader)
+	assert.Equal(t, InvalidMethodError{"NewSpatialIndex", "GeoLite2-ASN"}, err)
+}
+
+func networkStrings(matches []SpatialMatch) []string {
+	networks := make([]string, len(matches))
+	for i, m := range matches {
+		networks[i] = m.Network.String()
+	}
+	return networks
+}
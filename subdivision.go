@@ -0,0 +1,110 @@
+package geoip2
+
+// LeastSpecificSubdivision returns the largest subdivision of the record,
+// e.g., England for an address in London, or a zero Subdivision if the
+// record has none.
+func (c *City) LeastSpecificSubdivision() Subdivision {
+	if len(c.Subdivisions) == 0 {
+		return Subdivision{}
+	}
+	return c.Subdivisions[0]
+}
+
+// MostSpecificSubdivision returns the smallest subdivision of the record or
+// a zero Subdivision if the record has none. It is the same as
+// LeastSpecificSubdivision if the record has a single subdivision.
+func (c *City) MostSpecificSubdivision() Subdivision {
+	if len(c.Subdivisions) == 0 {
+		return Subdivision{}
+	}
+	return c.Subdivisions[len(c.Subdivisions)-1]
+}
+
+// SubdivisionISOCodes returns the full ISO 3166-2 codes of the subdivisions
+// of the record, e.g., "GB-ENG", from the least to the most specific. Codes
+// are only returned for subdivisions that have one and if the record has a
+// country.
+func (c *City) SubdivisionISOCodes() []string {
+	codes := make([]string, 0, len(c.Subdivisions))
+	for _, sub := range c.Subdivisions {
+		codes = appendISO3166Code(codes, c.Country.IsoCode, sub.IsoCode)
+	}
+	return codes
+}
+
+// PlaceHierarchy returns the names of the continent, country, subdivisions
+// and city of the record in locale, from the least to the most specific,
+// e.g., [Europe United Kingdom England London] for "en". Places without a
+// name in locale are skipped.
+func (c *City) PlaceHierarchy(locale string) []string {
+	names := make([]map[string]string, 0, len(c.Subdivisions)+3)
+	names = append(names, c.Continent.Names, c.Country.Names)
+	for _, sub := range c.Subdivisions {
+		names = append(names, sub.Names)
+	}
+	names = append(names, c.City.Names)
+	return placeNames(names, locale)
+}
+
+// LeastSpecificSubdivision returns the largest subdivision of the record,
+// e.g., England for an address in London, or a zero EnterpriseSubdivision
+// if the record has none.
+func (e *Enterprise) LeastSpecificSubdivision() EnterpriseSubdivision {
+	if len(e.Subdivisions) == 0 {
+		return EnterpriseSubdivision{}
+	}
+	return e.Subdivisions[0]
+}
+
+// MostSpecificSubdivision returns the smallest subdivision of the record or
+// a zero EnterpriseSubdivision if the record has none. It is the same as
+// LeastSpecificSubdivision if the record has a single subdivision.
+func (e *Enterprise) MostSpecificSubdivision() EnterpriseSubdivision {
+	if len(e.Subdivisions) == 0 {
+		return EnterpriseSubdivision{}
+	}
+	return e.Subdivisions[len(e.Subdivisions)-1]
+}
+
+// SubdivisionISOCodes returns the full ISO 3166-2 codes of the subdivisions
+// of the record, e.g., "GB-ENG", from the least to the most specific. Codes
+// are only returned for subdivisions that have one and if the record has a
+// country.
+func (e *Enterprise) SubdivisionISOCodes() []string {
+	codes := make([]string, 0, len(e.Subdivisions))
+	for _, sub := range e.Subdivisions {
+		codes = appendISO3166Code(codes, e.Country.IsoCode, sub.IsoCode)
+	}
+	return codes
+}
+
+// PlaceHierarchy returns the names of the continent, country, subdivisions
+// and city of the record in locale, from the least to the most specific,
+// e.g., [Europe United Kingdom England London] for "en". Places without a
+// name in locale are skipped.
+func (e *Enterprise) PlaceHierarchy(locale string) []string {
+	names := make([]map[string]string, 0, len(e.Subdivisions)+3)
+	names = append(names, e.Continent.Names, e.Country.Names)
+	for _, sub := range e.Subdivisions {
+		names = append(names, sub.Names)
+	}
+	names = append(names, e.City.Names)
+	return placeNames(names, locale)
+}
+
+func appendISO3166Code(codes []string, country, subdivision string) []string {
+	if country == "" || subdivision == "" {
+		return codes
+	}
+	return append(codes, country+"-"+subdivision)
+}
+
+func placeNames(names []map[string]string, locale string) []string {
+	places := make([]string, 0, len(names))
+	for _, n := range names {
+		if name := n[locale]; name != "" {
+			places = append(places, name)
+		}
+	}
+	return places
+}
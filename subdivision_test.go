@@ -0,0 +1,57 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCitySubdivisions(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.City(net.ParseIP("2.125.160.216"))
+	require.NoError(t, err)
+	require.Len(t, record.Subdivisions, 2)
+
+	assert.Equal(t, "ENG", record.LeastSpecificSubdivision().IsoCode)
+	assert.Equal(t, "WBK", record.MostSpecificSubdivision().IsoCode)
+	assert.Equal(t, []string{"GB-ENG", "GB-WBK"}, record.SubdivisionISOCodes())
+	assert.Equal(t,
+		[]string{"Europe", "United Kingdom", "England", "West Berkshire", "Boxford"},
+		record.PlaceHierarchy("en"),
+	)
+	assert.Empty(t, record.PlaceHierarchy("tlh"))
+
+	record, err = reader.City(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Equal(t, Subdivision{}, record.LeastSpecificSubdivision())
+	assert.Equal(t, Subdivision{}, record.MostSpecificSubdivision())
+	assert.Empty(t, record.SubdivisionISOCodes())
+	assert.Empty(t, record.PlaceHierarchy("en"))
+}
+
+func TestEnterpriseSubdivisions(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-Enterprise-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.Enterprise(net.ParseIP("74.209.24.0"))
+	require.NoError(t, err)
+	require.NotEmpty(t, record.Subdivisions)
+
+	assert.Equal(t, record.Subdivisions[0], record.LeastSpecificSubdivision())
+	assert.Equal(t, record.Subdivisions[len(record.Subdivisions)-1], record.MostSpecificSubdivision())
+	assert.Equal(t, []string{"US-NY"}, record.SubdivisionISOCodes())
+
+	places := record.PlaceHierarchy("en")
+	require.NotEmpty(t, places)
+	assert.Equal(t, "North America", places[0])
+	assert.Equal(t, record.City.Names["en"], places[len(places)-1])
+
+	var empty Enterprise
+	assert.Equal(t, EnterpriseSubdivision{}, empty.MostSpecificSubdivision())
+}
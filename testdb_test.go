@@ -0,0 +1,185 @@
+package geoip2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDatabase builds an IPv4 database of databaseType holding records,
+// which are keyed by network in CIDR notation and use the keys of the
+// database format, e.g., "city" or "iso_code", and opens it. It allows
+// tests to use records that the test databases do not contain.
+func testDatabase(t *testing.T, databaseType string, records map[string]map[string]any) *Reader {
+	t.Helper()
+
+	// The search tree is built as a binary trie whose leaves are offsets in
+	// the data section.
+	type node struct {
+		children [2]*node
+		data     int
+		leaf     bool
+	}
+	root := &node{}
+	var data bytes.Buffer
+	networks := make([]string, 0, len(records))
+	for network := range records {
+		networks = append(networks, network)
+	}
+	slices.Sort(networks)
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		require.NoError(t, err)
+		ip := ipNet.IP.To4()
+		require.NotNil(t, ip, "only IPv4 networks are supported")
+		prefix, _ := ipNet.Mask.Size()
+
+		n := root
+		for i := range prefix {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if n.children[bit] == nil {
+				n.children[bit] = &node{}
+			}
+			n = n.children[bit]
+		}
+		n.leaf = true
+		n.data = data.Len()
+		encodeTestValue(t, &data, records[network])
+	}
+
+	var nodes []*node
+	ids := map[*node]int{}
+	queue := []*node{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		ids[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, child := range n.children {
+			if child != nil && !child.leaf {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	const dataSectionSeparator = 16
+	var db bytes.Buffer
+	for _, n := range nodes {
+		for _, child := range n.children {
+			record := len(nodes)
+			switch {
+			case child == nil:
+			case child.leaf:
+				record = len(nodes) + dataSectionSeparator + child.data
+			default:
+				record = ids[child]
+			}
+			db.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+	db.Write(make([]byte, dataSectionSeparator))
+	db.Write(data.Bytes())
+	db.WriteString("\xab\xcd\xefMaxMind.com")
+	encodeTestValue(t, &db, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Now().Unix()),
+		"database_type":               databaseType,
+		"description":                 map[string]any{"en": "Test database"},
+		"ip_version":                  uint16(4),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(len(nodes)),
+		"record_size":                 uint16(24),
+	})
+
+	reader, err := FromBytes(db.Bytes())
+	require.NoError(t, err)
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
+
+// encodeTestValue appends value to buf in the data section format. Integers
+// must have the width of the field they are decoded into, except for int,
+// which is encoded as a uint32.
+func encodeTestValue(t *testing.T, buf *bytes.Buffer, value any) {
+	t.Helper()
+
+	switch v := value.(type) {
+	case map[string]any:
+		writeTestControl(buf, 7, len(v))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			encodeTestValue(t, buf, key)
+			encodeTestValue(t, buf, v[key])
+		}
+	case []any:
+		writeTestControl(buf, 11, len(v))
+		for _, element := range v {
+			encodeTestValue(t, buf, element)
+		}
+	case string:
+		writeTestControl(buf, 2, len(v))
+		buf.WriteString(v)
+	case float64:
+		writeTestControl(buf, 3, 8)
+		require.NoError(t, binary.Write(buf, binary.BigEndian, math.Float64bits(v)))
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		writeTestControl(buf, 14, size)
+	case uint16:
+		writeTestUint(buf, 5, uint64(v))
+	case uint32:
+		writeTestUint(buf, 6, uint64(v))
+	case int:
+		writeTestUint(buf, 6, uint64(v))
+	case uint64:
+		writeTestUint(buf, 9, v)
+	default:
+		t.Fatalf("cannot encode %T", value)
+	}
+}
+
+func writeTestUint(buf *bytes.Buffer, typ int, v uint64) {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	writeTestControl(buf, typ, len(b))
+	buf.Write(b)
+}
+
+func writeTestControl(buf *bytes.Buffer, typ, size int) {
+	control := byte(0)
+	if typ <= 7 {
+		control = byte(typ << 5)
+	}
+	var sizeBytes []byte
+	switch {
+	case size < 29:
+		control |= byte(size)
+	case size < 285:
+		control |= 29
+		sizeBytes = []byte{byte(size - 29)}
+	default:
+		control |= 30
+		sizeBytes = []byte{byte((size - 285) >> 8), byte(size - 285)}
+	}
+	buf.WriteByte(control)
+	if typ > 7 {
+		buf.WriteByte(byte(typ - 7))
+	}
+	buf.Write(sizeBytes)
+}
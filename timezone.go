@@ -0,0 +1,72 @@
+package geoip2
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoTimeZone is returned when resolving the time zone of a Location
+// that has none, e.g., because the database has no location data for the
+// IP address.
+var ErrNoTimeZone = errors.New("geoip2: location has no time zone")
+
+// TimeZoneError is returned when the time zone of a Location cannot be
+// loaded. This usually means that the time zone database is not available
+// on the system. Importing the time/tzdata package, or building with
+// -tags timetzdata, embeds a copy of it in the program.
+type TimeZoneError struct {
+	Err      error
+	TimeZone string
+}
+
+func (e TimeZoneError) Error() string {
+	return fmt.Sprintf(`geoip2: loading time zone %q (is the time zone database available?): %v`,
+		e.TimeZone, e.Err)
+}
+
+func (e TimeZoneError) Unwrap() error {
+	return e.Err
+}
+
+type timeZoneResult struct {
+	location *time.Location
+	err      error
+}
+
+// timeZones caches the result of loading each time zone by name, as
+// time.LoadLocation reads and parses the time zone database on every call.
+var timeZones sync.Map
+
+// TimeLocation returns the *time.Location for the time zone of l. Time
+// zones are loaded once and cached for the life of the program.
+func (l Location) TimeLocation() (*time.Location, error) {
+	if l.TimeZone == "" {
+		return nil, ErrNoTimeZone
+	}
+	if cached, ok := timeZones.Load(l.TimeZone); ok {
+		result := cached.(timeZoneResult)
+		return result.location, result.err
+	}
+
+	var result timeZoneResult
+	result.location, result.err = time.LoadLocation(l.TimeZone)
+	if result.err != nil {
+		result.location = nil
+		result.err = TimeZoneError{Err: result.err, TimeZone: l.TimeZone}
+	}
+	timeZones.Store(l.TimeZone, result)
+	return result.location, result.err
+}
+
+// LocalTime returns t in the time zone of l, i.e., the local time at the
+// location when t occurred. Use time.Now() as t to get the current local
+// time.
+func (l Location) LocalTime(t time.Time) (time.Time, error) {
+	location, err := l.TimeLocation()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(location), nil
+}
@@ -0,0 +1,53 @@
+package geoip2
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationTimeLocation(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	record, err := reader.City(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+
+	location, err := record.Location.TimeLocation()
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/London", location.String())
+
+	cached, err := record.Location.TimeLocation()
+	require.NoError(t, err)
+	assert.Same(t, location, cached)
+
+	summer := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	local, err := record.Location.LocalTime(summer)
+	require.NoError(t, err)
+	assert.Equal(t, 13, local.Hour())
+	assert.True(t, local.Equal(summer))
+
+	record, err = reader.City(net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	_, err = record.Location.TimeLocation()
+	assert.ErrorIs(t, err, ErrNoTimeZone)
+	_, err = record.Location.LocalTime(summer)
+	assert.ErrorIs(t, err, ErrNoTimeZone)
+}
+
+func TestLocationTimeLocationUnknown(t *testing.T) {
+	l := Location{TimeZone: "Nowhere/Atlantis"}
+	_, err := l.TimeLocation()
+
+	var tzErr TimeZoneError
+	require.ErrorAs(t, err, &tzErr)
+	assert.Equal(t, "Nowhere/Atlantis", tzErr.TimeZone)
+	assert.Error(t, tzErr.Err)
+
+	_, cached := l.TimeLocation()
+	assert.Equal(t, err, cached)
+}
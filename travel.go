@@ -0,0 +1,166 @@
+package geoip2
+
+import (
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultMaxTravelSpeed is the speed, in kilometers per hour, used by a
+// TravelDetector when TravelConfig.MaxSpeed is not set. It is slightly
+// above the cruising speed of a commercial airliner.
+const DefaultMaxTravelSpeed = 1000
+
+// TravelEvent is an event attributed to a user, such as a login, that
+// happened at a known time from a known IP address.
+type TravelEvent struct {
+	Time time.Time
+	User string
+	IP   net.IP
+}
+
+// TravelAlert describes two events of the same user whose locations are too
+// far apart to have been travelled between in the time that separates them.
+type TravelAlert struct {
+	Previous         TravelEvent
+	Current          TravelEvent
+	PreviousLocation Location
+	CurrentLocation  Location
+	// Distance is the smallest distance in kilometers that may separate the
+	// two locations, taking their accuracy radii into account.
+	Distance float64
+	// Speed is the implied speed in kilometers per hour. It is +Inf if the
+	// events happened at the same time.
+	Speed float64
+}
+
+// TravelConfig configures a TravelDetector.
+type TravelConfig struct {
+	// MaxSpeed is the highest plausible speed of travel in kilometers per
+	// hour. If it is zero, DefaultMaxTravelSpeed is used.
+	MaxSpeed float64
+}
+
+type travelPoint struct {
+	event    TravelEvent
+	location Location
+}
+
+// TravelDetector flags "impossible travel" between consecutive events of the
+// same user by comparing the speed implied by their locations with a
+// plausible maximum.
+//
+// To avoid false positives, the distance between two events is reduced by
+// the accuracy radii of both locations, and events whose IP address has no
+// location or belongs to an anycast network or a satellite provider are
+// ignored, as their location says little about where the user is.
+//
+// A TravelDetector is safe for concurrent use. It remembers the last
+// located event of every user it has seen; use Forget to remove users.
+type TravelDetector struct {
+	reader CityReader
+	last   map[string]travelPoint
+	config TravelConfig
+	mu     sync.Mutex
+}
+
+// NewTravelDetector returns a TravelDetector that locates events using
+// reader, which is typically a Reader for a City or Enterprise database.
+func NewTravelDetector(reader CityReader, config TravelConfig) *TravelDetector {
+	if config.MaxSpeed == 0 {
+		config.MaxSpeed = DefaultMaxTravelSpeed
+	}
+	return &TravelDetector{
+		reader: reader,
+		last:   map[string]travelPoint{},
+		config: config,
+	}
+}
+
+// Observe records event as the latest event of its user and compares it
+// with the previous one. It returns a TravelAlert if the travel between
+// them is impossible and nil otherwise. Events that cannot be reliably
+// located are neither compared nor recorded.
+func (d *TravelDetector) Observe(event TravelEvent) (*TravelAlert, error) {
+	location, ok, err := d.locate(event.IP)
+	if err != nil || !ok {
+		return nil, err
+	}
+	current := travelPoint{event: event, location: location}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous, seen := d.last[event.User]
+	if !seen || event.Time.After(previous.event.Time) {
+		d.last[event.User] = current
+	}
+	if !seen {
+		return nil, nil
+	}
+	return d.compare(previous, current), nil
+}
+
+// Compare compares two events directly, without recording them. It
+// returns a TravelAlert if the travel between them is impossible and nil
+// otherwise, including when either event cannot be reliably located.
+func (d *TravelDetector) Compare(previous, current TravelEvent) (*TravelAlert, error) {
+	previousLocation, ok, err := d.locate(previous.IP)
+	if err != nil || !ok {
+		return nil, err
+	}
+	currentLocation, ok, err := d.locate(current.IP)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return d.compare(
+		travelPoint{event: previous, location: previousLocation},
+		travelPoint{event: current, location: currentLocation},
+	), nil
+}
+
+// Forget removes the recorded event of user.
+func (d *TravelDetector) Forget(user string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.last, user)
+}
+
+func (d *TravelDetector) locate(ip net.IP) (Location, bool, error) {
+	record, err := d.reader.City(ip)
+	if err != nil {
+		return Location{}, false, err
+	}
+	if record.Traits.IsAnycast || record.Traits.IsSatelliteProvider ||
+		!record.Location.HasCoordinates() {
+		return Location{}, false, nil
+	}
+	return record.Location, true, nil
+}
+
+func (d *TravelDetector) compare(previous, current travelPoint) *TravelAlert {
+	distance := previous.location.MinDistanceTo(current.location)
+	if distance == 0 {
+		return nil
+	}
+
+	hours := math.Abs(current.event.Time.Sub(previous.event.Time).Hours())
+	speed := math.Inf(1)
+	if hours > 0 {
+		speed = distance / hours
+	}
+	if speed <= d.config.MaxSpeed {
+		return nil
+	}
+
+	return &TravelAlert{
+		Previous:         previous.event,
+		Current:          current.event,
+		PreviousLocation: previous.location,
+		CurrentLocation:  current.location,
+		Distance:         distance,
+		Speed:            speed,
+	}
+}
@@ -0,0 +1,81 @@
+package geoip2
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTravelDetector(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	d := NewTravelDetector(reader, TravelConfig{})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	london := net.ParseIP("81.2.69.160")
+	milton := net.ParseIP("216.160.83.56")
+
+	alert, err := d.Observe(TravelEvent{User: "alice", IP: london, Time: start})
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+
+	// Anycast networks and addresses without a location are ignored.
+	for _, ip := range []string{"214.1.1.0", "10.0.0.1"} {
+		alert, err = d.Observe(TravelEvent{User: "alice", IP: net.ParseIP(ip), Time: start})
+		require.NoError(t, err)
+		assert.Nil(t, alert)
+	}
+
+	alert, err = d.Observe(TravelEvent{User: "alice", IP: milton, Time: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	assert.Equal(t, london, alert.Previous.IP)
+	assert.Equal(t, milton, alert.Current.IP)
+	assert.InDelta(t, 7732.34-100-22, alert.Distance, 0.01)
+	assert.InDelta(t, 7732.34-100-22, alert.Speed, 0.01)
+
+	// Other users are tracked separately.
+	alert, err = d.Observe(TravelEvent{User: "bob", IP: london, Time: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+
+	alert, err = d.Observe(TravelEvent{User: "bob", IP: milton, Time: start.Add(12 * time.Hour)})
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+
+	d.Forget("bob")
+	alert, err = d.Observe(TravelEvent{User: "bob", IP: london, Time: start.Add(13 * time.Hour)})
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+}
+
+func TestTravelDetectorCompare(t *testing.T) {
+	reader, err := Open("test-data/test-data/GeoIP2-City-Test.mmdb")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	d := NewTravelDetector(reader, TravelConfig{MaxSpeed: 500})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	london := TravelEvent{User: "alice", IP: net.ParseIP("81.2.69.160"), Time: start}
+	milton := TravelEvent{User: "alice", IP: net.ParseIP("216.160.83.56"), Time: start}
+
+	alert, err := d.Compare(london, milton)
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	assert.True(t, math.IsInf(alert.Speed, 1))
+
+	milton.Time = start.Add(12 * time.Hour)
+	alert, err = d.Compare(london, milton)
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	assert.InDelta(t, (7732.34-122)/12, alert.Speed, 0.01)
+
+	alert, err = d.Compare(london, london)
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+}